@@ -2,10 +2,13 @@ package failure
 
 import (
 	"fmt"
+	"io"
 	"path"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync/atomic"
 )
 
 // CallStack represents a call stack.
@@ -20,7 +23,13 @@ type CallStack interface {
 }
 
 type callStack struct {
-	pcs []uintptr
+	pcs       []uintptr
+	truncated bool
+}
+
+// Truncated reports whether the call stack was cut off by the maximum depth.
+func (cs callStack) Truncated() bool {
+	return cs.truncated
 }
 
 func (cs callStack) HeadFrame() Frame {
@@ -54,6 +63,10 @@ func (cs callStack) Frames() []Frame {
 }
 
 func (cs callStack) Format(s fmt.State, verb rune) {
+	formatCallStack(s, verb, cs)
+}
+
+func formatCallStack(s fmt.State, verb rune, cs CallStack) {
 	switch verb {
 	case 'v':
 		switch {
@@ -61,6 +74,9 @@ func (cs callStack) Format(s fmt.State, verb rune) {
 			for _, f := range cs.Frames() {
 				fmt.Fprintf(s, "%+v\n", f)
 			}
+			if IsTruncated(cs) {
+				fmt.Fprintf(s, "%s\n", truncatedNote)
+			}
 		case s.Flag('#'):
 			fmt.Fprintf(s, "%#v", cs.Frames())
 		default:
@@ -82,18 +98,221 @@ func (cs callStack) Format(s fmt.State, verb rune) {
 // NewCallStack returns call stack from program counters.
 // You can use Callers for usual usage.
 func NewCallStack(pcs []uintptr) CallStack {
-	return callStack{pcs}
+	return callStack{pcs, false}
+}
+
+// frameCallStack is a call stack built from frames instead of program
+// counters (e.g. decoded from JSON).
+type frameCallStack struct {
+	frames    []Frame
+	truncated bool
+}
+
+func (cs frameCallStack) HeadFrame() Frame {
+	if len(cs.frames) == 0 {
+		return emptyFrame
+	}
+	return cs.frames[0]
+}
+
+func (cs frameCallStack) Frames() []Frame {
+	if len(cs.frames) == 0 {
+		return nil
+	}
+	return cs.frames
+}
+
+func (cs frameCallStack) Truncated() bool {
+	return cs.truncated
+}
+
+func (cs frameCallStack) Format(s fmt.State, verb rune) {
+	formatCallStack(s, verb, cs)
+}
+
+const truncatedNote = "... (truncated)"
+
+// IsTruncated reports whether the cs was cut off by the maximum depth
+// of call stacks.
+func IsTruncated(cs CallStack) bool {
+	t, ok := cs.(interface{ Truncated() bool })
+	return ok && t.Truncated()
+}
+
+// DefaultCallStackDepth is the default maximum depth of call stacks.
+const DefaultCallStackDepth = 32
+
+// UnlimitedCallStackDepth is a depth to capture entire call stacks.
+const UnlimitedCallStackDepth = -1
+
+var callStackDepth int32 = DefaultCallStackDepth
+
+// SetCallStackDepth sets the maximum depth of call stacks captured by
+// Callers and constructor functions such as New. If the depth is 0 or
+// negative, the depth is unlimited.
+// It should be called on initialization of the program.
+func SetCallStackDepth(depth int) {
+	atomic.StoreInt32(&callStackDepth, int32(depth))
+}
+
+// CallStackDepth is an option of New, Translate, Wrap, Unexpected and
+// MarkUnexpected to set the maximum depth of the call stack they capture.
+// It is given with wrappers, and the functions take it out of them.
+// If the depth is 0 or negative, the depth is unlimited.
+//
+//	failure.Wrap(err, failure.CallStackDepth(128))
+type CallStackDepth int
+
+// WrapError implements the Wrapper interface to be given with wrappers.
+// It returns the err as it is, thus CallStackDepth has no effect on
+// functions which do not capture a call stack (e.g. Custom).
+func (CallStackDepth) WrapError(err error) error {
+	return err
 }
 
 // Callers returns a call stack for the current state.
+// The depth of the call stack is limited by SetCallStackDepth.
 func Callers(skip int) CallStack {
-	var pcs [32]uintptr
-	n := runtime.Callers(skip+2, pcs[:])
-	if n == 0 {
+	return callers(skip+1, int(atomic.LoadInt32(&callStackDepth)))
+}
+
+// CallersDepth returns a call stack for the current state with the
+// maximum depth. If the depth is 0 or negative, the depth is unlimited.
+func CallersDepth(skip, depth int) CallStack {
+	return callers(skip+1, depth)
+}
+
+func callers(skip, depth int) CallStack {
+	pcs, truncated := capturePCs(skip+1, depth)
+	if len(pcs) == 0 {
 		return nil
 	}
+	return callStack{pcs, truncated}
+}
+
+// capturePCs returns program counters of the call stack from the caller
+// skipping first N frames.
+func capturePCs(skip, depth int) ([]uintptr, bool) {
+	if depth > 0 {
+		pcs := make([]uintptr, depth+1)
+		n := runtime.Callers(skip+2, pcs)
+		if n > depth {
+			return pcs[:depth], true
+		}
+		return pcs[:n], false
+	}
+
+	pcs := make([]uintptr, DefaultCallStackDepth*2)
+	for {
+		n := runtime.Callers(skip+2, pcs)
+		if n < len(pcs) {
+			return pcs[:n], false
+		}
+		pcs = make([]uintptr, len(pcs)*2)
+	}
+}
+
+// AllCallStacks returns a fmt.Formatter for internal debug output.
+// %+v prints the err like WithFormatter, but prints every distinct call
+// stack in the chain instead of only the deepest one.
+// It is useful when an error is wrapped again in another goroutine.
+// Frames shared with the previously printed call stack are elided as
+// "... N more". Other verbs print err.Error().
+//
+//	log.Printf("%+v", failure.AllCallStacks(err))
+func AllCallStacks(err error) fmt.Formatter {
+	return allCallStacksFormatter{err}
+}
 
-	return NewCallStack(pcs[:n])
+type allCallStacksFormatter struct {
+	err error
+}
+
+func (f allCallStacksFormatter) Format(s fmt.State, verb rune) {
+	if f.err == nil {
+		io.WriteString(s, "<nil>")
+		return
+	}
+	if verb != 'v' || !s.Flag('+') {
+		io.WriteString(s, f.err.Error())
+		return
+	}
+	formatChain(s, f.err, false)
+	formatCallStacks(s, f.err, true)
+}
+
+// formatCallStacks prints call stacks of the err for %+v.
+// If all is false, only the deepest call stack is printed.
+func formatCallStacks(s fmt.State, err error, all bool) {
+	if !all {
+		fmt.Fprint(s, "[CallStack]\n")
+		if cs, ok := CallStackOf(err); ok {
+			formatFrames(s, cs.Frames(), 0, IsTruncated(cs))
+		}
+		return
+	}
+
+	var (
+		prev          []Frame
+		prevTruncated bool
+		printed       bool
+	)
+	i := NewIterator(err)
+	for i.Next() {
+		var cs CallStack
+		if !i.As(&cs) {
+			continue
+		}
+		frames := cs.Frames()
+		truncated := IsTruncated(cs)
+		if prev != nil && truncated == prevTruncated && len(frames) == len(prev) &&
+			commonFrames(frames, prev) == len(frames) {
+			continue
+		}
+
+		// Bottom frames of truncated call stacks are not comparable.
+		shared := 0
+		if prev != nil && !truncated && !prevTruncated {
+			shared = commonFrames(frames, prev)
+			if shared == len(frames) && shared > 0 {
+				// Keep the head frame at least.
+				shared--
+			}
+		}
+
+		fmt.Fprint(s, "[CallStack]\n")
+		formatFrames(s, frames, shared, truncated)
+		prev, prevTruncated, printed = frames, truncated, true
+	}
+	if !printed {
+		fmt.Fprint(s, "[CallStack]\n")
+	}
+}
+
+// formatFrames prints the frames eliding the last shared frames.
+func formatFrames(s fmt.State, frames []Frame, shared int, truncated bool) {
+	for _, f := range frames[:len(frames)-shared] {
+		fmt.Fprintf(s, "    %+v\n", f)
+	}
+	if shared > 0 {
+		fmt.Fprintf(s, "    ... %d more\n", shared)
+	}
+	if truncated {
+		fmt.Fprintf(s, "    %s\n", truncatedNote)
+	}
+}
+
+// commonFrames returns the number of frames shared at the bottom of a and b.
+func commonFrames(a, b []Frame) int {
+	n := 0
+	for n < len(a) && n < len(b) {
+		fa, fb := a[len(a)-1-n], b[len(b)-1-n]
+		if fa.Path() != fb.Path() || fa.Line() != fb.Line() || fa.Func() != fb.Func() {
+			break
+		}
+		n++
+	}
+	return n
 }
 
 // Frame represents a stack frame.
@@ -105,11 +324,16 @@ type Frame interface {
 	// Line returns a line number in the file.
 	Line() int
 	// Func returns a function name.
+	// It includes the receiver for methods (e.g. (*Type).Method) and
+	// "[...]" for generic functions (e.g. Map[...]).
 	Func() string
 	// Pkg returns a package name of the function.
 	Pkg() string
 	// PkgPath returns a path-qualified package name of the function.
 	PkgPath() string
+	// Receiver returns a receiver type of the method (e.g. *Type).
+	// It returns empty string for functions.
+	Receiver() string
 	// PC returns a program counter of this frame.
 	PC() uintptr
 }
@@ -136,22 +360,16 @@ func (f frame) Line() int {
 }
 
 func (f frame) Func() string {
-	fs := strings.Split(path.Base(f.function), ".")
-	if len(fs) >= 1 {
-		return strings.Join(fs[1:], ".")
-	}
-	return fs[0]
+	_, fn := splitFunc(f.function)
+	return fn
 }
 
 func (f frame) PkgPath() string {
 	// e.g.
 	//   When f.function = github.com/morikuni/failure_test.TestFrame.func1.1
 	//   f.PkgPath() = github.com/morikuni/failure_test
-	lastSlash := strings.LastIndex(f.function, "/")
-	if lastSlash == -1 {
-		lastSlash = 0
-	}
-	return f.function[:strings.Index(f.function[lastSlash:], ".")+lastSlash]
+	pkgPath, _ := splitFunc(f.function)
+	return pkgPath
 }
 
 func (f frame) PC() uintptr {
@@ -159,8 +377,137 @@ func (f frame) PC() uintptr {
 }
 
 func (f frame) Pkg() string {
-	fs := strings.Split(path.Base(f.function), ".")
-	return fs[0]
+	return path.Base(f.PkgPath())
+}
+
+func (f frame) Receiver() string {
+	_, fn := splitFunc(f.function)
+	return receiverOf(fn)
+}
+
+// splitFunc splits a function name from runtime.Frame into the package
+// path and the function.
+// e.g.
+//
+//	github.com/morikuni/failure_test.TestFrame.func1.1
+//	  -> github.com/morikuni/failure_test, TestFrame.func1.1
+//	gopkg.in/yaml%2ev3.(*decoder).unmarshal
+//	  -> gopkg.in/yaml.v3, (*decoder).unmarshal
+//	example.com/list.(*List[...]).Push
+//	  -> example.com/list, (*List[...]).Push
+func splitFunc(function string) (pkgPath, fn string) {
+	// Type arguments may contain '/' and '.', but the package path
+	// never contains '['.
+	head := function
+	if i := strings.IndexByte(head, '['); i >= 0 {
+		head = head[:i]
+	}
+	// The last element of the package path does not contain '.' because
+	// it is escaped as %2e.
+	lastSlash := strings.LastIndexByte(head, '/')
+	dot := strings.IndexByte(head[lastSlash+1:], '.')
+	if dot == -1 {
+		return unescapePkgPath(function), ""
+	}
+	dot += lastSlash + 1
+	return unescapePkgPath(function[:dot]), function[dot+1:]
+}
+
+// unescapePkgPath decodes %xx in the package path escaped by the linker.
+func unescapePkgPath(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// escapePkgPath is the reverse of unescapePkgPath.
+func escapePkgPath(s string) string {
+	lastSlash := strings.LastIndexByte(s, '/')
+	return s[:lastSlash+1] + strings.Replace(s[lastSlash+1:], ".", "%2e", -1)
+}
+
+// receiverOf returns the receiver type of the function from splitFunc.
+// e.g.
+//
+//	(*Type).Method      -> *Type
+//	(*Type[...]).Method -> *Type[...]
+//	Type.Method.func1   -> Type
+//	Func.func1.2        -> (empty)
+func receiverOf(fn string) string {
+	if strings.HasPrefix(fn, "(") {
+		if i := strings.Index(fn, ")."); i != -1 {
+			return fn[1:i]
+		}
+		return ""
+	}
+
+	// Find the first '.' outside of type arguments.
+	depth, dot := 0, -1
+	for i := 0; i < len(fn) && dot == -1; i++ {
+		switch fn[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '.':
+			if depth == 0 {
+				dot = i
+			}
+		}
+	}
+	if dot == -1 {
+		return ""
+	}
+
+	name := fn[dot+1:]
+	if i := strings.IndexByte(name, '.'); i != -1 {
+		name = name[:i]
+	}
+	if isClosureName(strings.TrimSuffix(name, "-fm")) {
+		return ""
+	}
+	return fn[:dot]
+}
+
+// isClosureName reports whether the name is given by the compiler for
+// function literals (e.g. func1, gowrap1, 2).
+func isClosureName(name string) bool {
+	for _, prefix := range []string{"func", "gowrap", "deferwrap"} {
+		if strings.HasPrefix(name, prefix) {
+			name = name[len(prefix):]
+			break
+		}
+	}
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// funcNameOf returns the function name of the f in the form of
+// runtime.Frame.Function.
+func funcNameOf(f Frame) string {
+	if t, ok := f.(frame); ok {
+		return t.function
+	}
+	return escapePkgPath(f.PkgPath()) + "." + f.Func()
 }
 
 func (f frame) Format(s fmt.State, verb rune) {
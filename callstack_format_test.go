@@ -0,0 +1,43 @@
+package failure_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/morikuni/failure"
+)
+
+func TestAllCallStacks(t *testing.T) {
+	inner := func() error { return failure.New(TestCodeA) }
+	err := failure.Wrap(inner())
+
+	// Only the deepest call stack by default.
+	shouldMatch(t, fmt.Sprintf("%+v", err), `\[CallStack\]\n    \[failure_test\.TestAllCallStacks\.func1\] [^\n]+\n(    [^.][^\n]+\n)+$`)
+
+	shouldMatch(t, fmt.Sprintf("%+v", failure.AllCallStacks(err)), ``+
+		`\[CallStack\]\n`+
+		`    \[failure_test\.TestAllCallStacks\] /.+/failure/callstack_format_test.go:13\n`+
+		`(    [^.][^\n]+\n)+`+
+		`\[CallStack\]\n`+
+		`    \[failure_test\.TestAllCallStacks\.func1\] /.+/failure/callstack_format_test.go:12\n`+
+		`    \.\.\. \d+ more\n$`,
+	)
+	shouldEqual(t, fmt.Sprintf("%v", failure.AllCallStacks(err)), err.Error())
+	shouldEqual(t, fmt.Sprintf("%+v", failure.AllCallStacks(nil)), "<nil>")
+
+	c := make(chan error)
+	go func() {
+		c <- failure.Unexpected("xxx")
+	}()
+	err = failure.Wrap(<-c)
+	out := fmt.Sprintf("%+v", failure.AllCallStacks(err))
+	shouldMatch(t, out, `\[CallStack\]\n    \[failure_test\.TestAllCallStacks\] /.+/failure/callstack_format_test.go:33\n`)
+	shouldMatch(t, out, `\[CallStack\]\n    \[failure_test\.TestAllCallStacks\.func2\] /.+/failure/callstack_format_test.go:31\n`)
+
+	// The same call stack is printed once.
+	cs := failure.Callers(0)
+	err = failure.Custom(failure.Unexpected("xxx"), failure.WithCallStack(cs))
+	err = failure.Custom(err, failure.WithCallStack(cs))
+	shouldEqual(t, strings.Count(fmt.Sprintf("%+v", failure.AllCallStacks(err)), "[CallStack]"), 2)
+}
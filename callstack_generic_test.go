@@ -0,0 +1,30 @@
+package failure_test
+
+import (
+	"testing"
+
+	"github.com/morikuni/failure"
+)
+
+func genericFrame[T any]() failure.Frame {
+	return failure.Callers(0).HeadFrame()
+}
+
+type genericList[T any] struct{}
+
+func (*genericList[T]) Frame() failure.Frame {
+	return failure.Callers(0).HeadFrame()
+}
+
+func TestFrame_Generics(t *testing.T) {
+	f := genericFrame[int]()
+	shouldEqual(t, f.Func(), "genericFrame[...]")
+	shouldEqual(t, f.Pkg(), "failure_test")
+	shouldEqual(t, f.PkgPath(), "github.com/morikuni/failure_test")
+	shouldEqual(t, f.Receiver(), "")
+
+	f = (&genericList[string]{}).Frame()
+	shouldEqual(t, f.Func(), "(*genericList[...]).Frame")
+	shouldEqual(t, f.Pkg(), "failure_test")
+	shouldEqual(t, f.Receiver(), "*genericList[...]")
+}
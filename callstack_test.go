@@ -99,10 +99,125 @@ func TestFrame(t *testing.T) {
 		}()
 	}()
 
-	shouldEqual(t, f.Func(), "TestFrame.func1.1")
+	// The name of nested closures depends on the Go version.
+	shouldMatch(t, f.Func(), `^TestFrame\.func1\.(func)?1$`)
 	shouldEqual(t, f.Line(), 98)
 	shouldEqual(t, f.File(), "callstack_test.go")
 	shouldContain(t, f.Path(), "/failure/callstack_test.go")
 	shouldEqual(t, f.Pkg(), "failure_test")
 	shouldEqual(t, f.PkgPath(), "github.com/morikuni/failure_test")
+	shouldEqual(t, f.Receiver(), "")
+}
+
+func recurse(n int, f func() error) error {
+	if n == 0 {
+		return f()
+	}
+	return recurse(n-1, f)
+}
+
+func TestCallStackDepth(t *testing.T) {
+	deep := func(f func() error) failure.CallStack {
+		cs, ok := failure.CallStackOf(recurse(100, f))
+		shouldEqual(t, ok, true)
+		return cs
+	}
+
+	cs := deep(func() error { return failure.New(TestCodeA) })
+	shouldEqual(t, len(cs.Frames()), failure.DefaultCallStackDepth)
+	shouldEqual(t, failure.IsTruncated(cs), true)
+
+	cs = deep(func() error { return failure.New(TestCodeA, failure.CallStackDepth(50)) })
+	shouldEqual(t, len(cs.Frames()), 50)
+	shouldEqual(t, failure.IsTruncated(cs), true)
+
+	cs = deep(func() error { return failure.New(TestCodeA, failure.CallStackDepth(failure.UnlimitedCallStackDepth)) })
+	shouldEqual(t, len(cs.Frames()) > 100, true)
+	shouldEqual(t, failure.IsTruncated(cs), false)
+
+	failure.SetCallStackDepth(10)
+	defer failure.SetCallStackDepth(failure.DefaultCallStackDepth)
+	err := recurse(100, func() error { return failure.Unexpected("xxx") })
+	cs, _ = failure.CallStackOf(err)
+	shouldEqual(t, len(cs.Frames()), 10)
+	shouldMatch(t, fmt.Sprintf("%+v", err), `\[CallStack\]\n(    .+\n){10}    \.\.\. \(truncated\)\n$`)
+	shouldMatch(t, fmt.Sprintf("%+v", cs), `\.\.\. \(truncated\)\n$`)
+
+	cs = failure.CallersDepth(0, 0)
+	shouldEqual(t, failure.IsTruncated(cs), false)
+	shouldEqual(t, failure.IsTruncated(failure.Callers(0)), false)
+	shouldEqual(t, failure.IsTruncated(failure.NewCallStack(nil)), false)
+}
+
+type frameReceiver struct{}
+
+func (frameReceiver) Value() failure.Frame {
+	return failure.Callers(0).HeadFrame()
+}
+
+func (*frameReceiver) Pointer() failure.Frame {
+	return func() failure.Frame {
+		return failure.Callers(0).HeadFrame()
+	}()
+}
+
+func TestFrame_Receiver(t *testing.T) {
+	var r frameReceiver
+
+	f := r.Value()
+	shouldEqual(t, f.Func(), "frameReceiver.Value")
+	shouldEqual(t, f.Receiver(), "frameReceiver")
+	shouldEqual(t, f.PkgPath(), "github.com/morikuni/failure_test")
+
+	f = r.Pointer()
+	shouldMatch(t, f.Func(), `^\(\*frameReceiver\)\.Pointer\.func1$`)
+	shouldEqual(t, f.Receiver(), "*frameReceiver")
+	shouldEqual(t, f.Pkg(), "failure_test")
+
+	value := r.Value
+	f = value()
+	shouldEqual(t, f.Receiver(), "frameReceiver")
+}
+
+func TestFrame_FuncName(t *testing.T) {
+	tests := []struct {
+		function string
+		pkgPath  string
+		pkg      string
+		fn       string
+		receiver string
+	}{
+		{"main.main", "main", "main", "main", ""},
+		{"main.main.func1.2", "main", "main", "main.func1.2", ""},
+		{"gopkg.in/yaml%2ev3.(*decoder).unmarshal", "gopkg.in/yaml.v3", "yaml.v3", "(*decoder).unmarshal", "*decoder"},
+		{"gopkg.in/yaml%2ev3.Unmarshal.func1", "gopkg.in/yaml.v3", "yaml.v3", "Unmarshal.func1", ""},
+		{"example.com/x.Map[...]", "example.com/x", "x", "Map[...]", ""},
+		{"example.com/x.Map[...].func1", "example.com/x", "x", "Map[...].func1", ""},
+		{"example.com/x.Map[go.shape.struct { example.com/y.Z }]", "example.com/x", "x", "Map[go.shape.struct { example.com/y.Z }]", ""},
+		{"example.com/x.(*List[...]).Push", "example.com/x", "x", "(*List[...]).Push", "*List[...]"},
+		{"example.com/x.List[...].Len", "example.com/x", "x", "List[...].Len", "List[...]"},
+		{"example.com/x.List[...].Len-fm", "example.com/x", "x", "List[...].Len-fm", "List[...]"},
+		{"example.com/x.T.M.gowrap1", "example.com/x", "x", "T.M.gowrap1", "T"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.function, func(t *testing.T) {
+			data := fmt.Sprintf(`{"chain":[{"call_stack":[{"func":%q,"file":"/src/x.go","line":1}]}]}`, test.function)
+			var err error
+			shouldEqual(t, failure.Unmarshal([]byte(data), &err, nil), nil)
+			cs, ok := failure.CallStackOf(err)
+			shouldEqual(t, ok, true)
+
+			f := cs.HeadFrame()
+			shouldEqual(t, f.PkgPath(), test.pkgPath)
+			shouldEqual(t, f.Pkg(), test.pkg)
+			shouldEqual(t, f.Func(), test.fn)
+			shouldEqual(t, f.Receiver(), test.receiver)
+			shouldEqual(t, fmt.Sprintf("%+v", f), fmt.Sprintf("[%s.%s] /src/x.go:1", test.pkg, test.fn))
+
+			b, e := failure.Marshal(err)
+			shouldEqual(t, e, nil)
+			shouldContain(t, string(b), fmt.Sprintf(`"func":%q`, test.function))
+		})
+	}
 }
@@ -1,6 +1,12 @@
 package failure
 
+import (
+	"fmt"
+	"reflect"
+)
+
 // Is checks whether an error code from the err is any of given code.
+// A code also matches its ancestors declared by ChildCode.
 func Is(err error, codes ...Code) bool {
 	if len(codes) == 0 {
 		return false
@@ -13,7 +19,7 @@ func Is(err error, codes ...Code) bool {
 	}
 
 	for i := range codes {
-		if c == codes[i] {
+		if CodeIs(c, codes[i]) {
 			return true
 		}
 	}
@@ -22,6 +28,7 @@ func Is(err error, codes ...Code) bool {
 
 // Code represents an error code of an error.
 // The code should be able to be compared by == operator.
+// Otherwise, the code should implement EqualCoder.
 // Basically, it should to be defined as constants.
 //
 // You can also define your own code type instead of using StringCode type,
@@ -33,9 +40,111 @@ type Code interface {
 }
 
 // StringCode represents an error Code in string.
+// It also implements error interface so that it can be used as a target
+// of errors.Is (e.g. errors.Is(err, NotFound)).
 type StringCode string
 
 // ErrorCode implements the Code interface.
 func (c StringCode) ErrorCode() string {
 	return string(c)
 }
+
+// Error implements the error interface.
+func (c StringCode) Error() string {
+	return string(c)
+}
+
+// EqualCoder is a Code which defines the equality of codes by itself.
+// It is used for codes which cannot be compared by == operator (e.g. codes
+// backed by slices or maps) or codes which should be compared by a part of
+// their values.
+type EqualCoder interface {
+	Code
+	// EqualCode reports whether the code equals to the other code.
+	// The other code is never nil.
+	EqualCode(other Code) bool
+}
+
+// EqualCode reports whether the codes are equal.
+// If either code implements EqualCoder, its EqualCode method is used.
+// If either code is PayloadCode, they are equal if they are the same kind.
+// Otherwise, codes are compared by == operator, and codes which cannot be
+// compared by == operator are compared by reflect.DeepEqual instead of
+// panic.
+func EqualCode(a, b Code) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if ec, ok := a.(EqualCoder); ok {
+		return ec.EqualCode(b)
+	}
+	if ec, ok := b.(EqualCoder); ok {
+		return ec.EqualCode(a)
+	}
+	if isPayload, same := sameKindCode(a, b); isPayload {
+		return same
+	}
+	if !isComparableCode(a) || !isComparableCode(b) {
+		return reflect.DeepEqual(a, b)
+	}
+	return a == b
+}
+
+// isComparableCode reports whether the code can be compared by ==
+// operator without panic, that is, it can be a map key.
+// Codes of comparable types holding incomparable values in interface
+// fields are also reported as not comparable.
+func isComparableCode(c Code) bool {
+	return c == nil || reflect.ValueOf(c).Comparable()
+}
+
+// isMapKeyCode reports whether the code can be looked up in maps keyed
+// by codes. Codes implementing EqualCoder or PayloadCode have to be
+// compared one by one.
+func isMapKeyCode(c Code) bool {
+	switch c.(type) {
+	case EqualCoder, PayloadCode:
+		return false
+	}
+	return isComparableCode(c)
+}
+
+// mustBeComparableCode panics with a clear message if the code cannot be
+// a map key.
+func mustBeComparableCode(fn string, c Code) {
+	if !isComparableCode(c) {
+		panic(fmt.Sprintf("failure: %s: code of type %T is not comparable by == operator", fn, c))
+	}
+}
+
+// ErrorCodeOf returns code.ErrorCode(), or an error if the code is nil or
+// a nil pointer, whose methods would panic.
+// Use it instead of calling ErrorCode directly for codes from errors.
+func ErrorCodeOf(code Code) (string, error) {
+	if code == nil {
+		return "", fmt.Errorf("failure: nil code")
+	}
+	if isNilPointerCode(code) {
+		return "", fmt.Errorf("failure: nil pointer code of type %T", code)
+	}
+	return code.ErrorCode(), nil
+}
+
+// isNilPointerCode reports whether the code is a nil pointer.
+func isNilPointerCode(c Code) bool {
+	v := reflect.ValueOf(c)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// codeString returns c.ErrorCode() for printing.
+// Invalid codes are printed like fmt does for bad verbs.
+func codeString(c Code) string {
+	if c == nil {
+		return "<nil>"
+	}
+	s, err := ErrorCodeOf(c)
+	if err != nil {
+		return fmt.Sprintf("%%!code(%T=<nil>)", c)
+	}
+	return s
+}
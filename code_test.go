@@ -2,6 +2,7 @@ package failure_test
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"testing"
 
@@ -61,3 +62,252 @@ func TestIs(t *testing.T) {
 	shouldEqual(t, failure.Is(nil, nil), true)
 	shouldEqual(t, failure.Is(errors.New("error"), nil), true)
 }
+
+func TestCodeOf_MultiError(t *testing.T) {
+	errA := failure.New(TestCodeA)
+	errB := failure.Translate(failure.New(TestCodeA), TestCodeB)
+	errU := failure.MarkUnexpected(failure.New(TestCodeB))
+
+	tests := map[string]struct {
+		err       error
+		wantCode  failure.Code
+		wantCodes []failure.Code
+	}{
+		"join": {
+			err:       errors.Join(io.EOF, errA, errB),
+			wantCode:  TestCodeA,
+			wantCodes: []failure.Code{TestCodeA, TestCodeB},
+		},
+		"unexpected branch": {
+			err:       errors.Join(errU, errB),
+			wantCode:  TestCodeB,
+			wantCodes: []failure.Code{TestCodeB},
+		},
+		"unexpected root": {
+			err:       failure.MarkUnexpected(errors.Join(errA, errB)),
+			wantCode:  nil,
+			wantCodes: nil,
+		},
+		"translate join": {
+			err:       failure.Translate(errors.Join(errA, errB), TestCodeB),
+			wantCode:  TestCodeB,
+			wantCodes: []failure.Code{TestCodeB},
+		},
+		"errorf": {
+			err:       fmt.Errorf("%w, %w", io.EOF, failure.Wrap(errB)),
+			wantCode:  TestCodeB,
+			wantCodes: []failure.Code{TestCodeB},
+		},
+	}
+
+	for title, test := range tests {
+		t.Run(title, func(t *testing.T) {
+			code, ok := failure.CodeOf(test.err)
+			shouldEqual(t, ok, test.wantCode != nil)
+			shouldEqual(t, code, test.wantCode)
+			shouldEqual(t, failure.CodesOf(test.err), test.wantCodes)
+		})
+	}
+}
+
+func TestErrorsIs_Code(t *testing.T) {
+	const (
+		A failure.StringCode = "A"
+		B failure.StringCode = "B"
+	)
+
+	errA := failure.New(A, failure.Message("xxx"))
+	tests := map[string]struct {
+		err     error
+		wantA   bool
+		wantB   bool
+		wantMsg bool
+	}{
+		"new":             {errA, true, false, true},
+		"translate":       {failure.Translate(errA, B), false, true, true},
+		"wrap":            {fmt.Errorf("wrap: %w", failure.Wrap(errA)), true, false, true},
+		"mark unexpected": {failure.MarkUnexpected(errA), false, false, true},
+		"join":            {errors.Join(failure.Translate(errA, B), failure.MarkUnexpected(errA)), false, true, true},
+		"unexpected":      {failure.Unexpected("xxx"), false, false, false},
+	}
+
+	for title, test := range tests {
+		t.Run(title, func(t *testing.T) {
+			shouldEqual(t, errors.Is(test.err, A), test.wantA)
+			shouldEqual(t, errors.Is(test.err, B), test.wantB)
+
+			var code failure.StringCode
+			ok := errors.As(test.err, &code)
+			wantCode, wantOK := failure.CodeOf(test.err)
+			shouldEqual(t, ok, wantOK)
+			if ok {
+				shouldEqual(t, code, wantCode)
+			}
+
+			// Errors and data other than codes are still found.
+			shouldEqual(t, errors.Is(test.err, errA), test.wantMsg)
+			var msg failure.Messenger
+			shouldEqual(t, errors.As(test.err, &msg), test.wantMsg)
+		})
+	}
+
+	err := failure.Translate(io.EOF, A)
+	shouldEqual(t, errors.Is(err, io.EOF), true)
+	shouldEqual(t, failure.CauseOf(err), io.EOF)
+}
+
+func TestUnwrap_CodeHidingView(t *testing.T) {
+	const A failure.StringCode = "A"
+
+	cause := &customError{"cause"}
+	err := failure.New(TestCodeA, failure.Message("xxx"))
+	for _, w := range []error{
+		failure.Custom(err, failure.WithCode(A)),
+		failure.Custom(err, failure.WithUnexpected()),
+	} {
+		view := errors.Unwrap(w)
+		shouldEqual(t, view == err, false)
+		shouldEqual(t, errors.Is(view, TestCodeA), false)
+		var code failure.StringCode
+		shouldEqual(t, errors.As(view, &code), false)
+	}
+
+	shouldEqual(t, errors.Is(failure.Translate(err, A), err), true)
+	shouldEqual(t, errors.Is(failure.MarkUnexpected(err), err), true)
+	wrapped := failure.Translate(failure.Wrap(cause), A)
+	shouldEqual(t, errors.Is(wrapped, cause), true)
+	var ce *customError
+	shouldEqual(t, errors.As(wrapped, &ce), true)
+	shouldEqual(t, ce, cause)
+	shouldEqual(t, failure.CauseOf(wrapped), error(cause))
+	msg, _ := failure.MessageOf(failure.Translate(err, A))
+	shouldEqual(t, msg, "xxx")
+}
+
+type customError struct {
+	msg string
+}
+
+func (e *customError) Error() string {
+	return e.msg
+}
+
+type sliceCode []string
+
+func (c sliceCode) ErrorCode() string {
+	return fmt.Sprint([]string(c))
+}
+
+type versionedCode struct {
+	name    string
+	version int
+}
+
+func (c versionedCode) ErrorCode() string {
+	return c.name
+}
+
+func (c versionedCode) EqualCode(other failure.Code) bool {
+	o, ok := other.(versionedCode)
+	return ok && o.name == c.name
+}
+
+type pointerCode struct {
+	name string
+}
+
+func (c *pointerCode) ErrorCode() string {
+	return c.name
+}
+
+func TestEqualCode(t *testing.T) {
+	shouldEqual(t, failure.EqualCode(TestCodeA, TestCodeA), true)
+	shouldEqual(t, failure.EqualCode(TestCodeA, TestCodeB), false)
+	shouldEqual(t, failure.EqualCode(nil, nil), true)
+	shouldEqual(t, failure.EqualCode(TestCodeA, nil), false)
+	shouldEqual(t, failure.EqualCode(sliceCode{"a"}, sliceCode{"a"}), true)
+	shouldEqual(t, failure.EqualCode(sliceCode{"a"}, sliceCode{"b"}), false)
+	shouldEqual(t, failure.EqualCode(versionedCode{"a", 1}, versionedCode{"a", 2}), true)
+	shouldEqual(t, failure.EqualCode(TestCodeA, versionedCode{"a", 2}), false)
+}
+
+func TestIs_NonComparableCode(t *testing.T) {
+	err := failure.New(sliceCode{"a"})
+
+	shouldEqual(t, failure.Is(err, sliceCode{"a"}), true)
+	shouldEqual(t, failure.Is(err, sliceCode{"b"}), false)
+	shouldEqual(t, failure.Is(err, TestCodeA), false)
+	shouldEqual(t, errors.Is(err, failure.New(sliceCode{"a"})), false)
+	shouldEqual(t, failure.Is(failure.New(TestCodeA), sliceCode{"a"}), false)
+
+	code, ok := failure.CodeOf(err)
+	shouldEqual(t, ok, true)
+	shouldEqual(t, code, sliceCode{"a"})
+	shouldEqual(t, err.Error(), "failure_test.TestIs_NonComparableCode: code([a])")
+
+	err = failure.New(versionedCode{"a", 1})
+	shouldEqual(t, failure.Is(err, versionedCode{"a", 2}), true)
+	shouldEqual(t, failure.Is(err, versionedCode{"b", 1}), false)
+}
+
+func TestNilCode(t *testing.T) {
+	err := failure.New(nil)
+	shouldEqual(t, err.Error(), "failure_test.TestNilCode: code(<nil>)")
+	shouldContain(t, fmt.Sprintf("%+v", err), "code(<nil>)")
+
+	code, ok := failure.CodeOf(err)
+	shouldEqual(t, ok, false)
+	shouldEqual(t, code, nil)
+	shouldEqual(t, failure.Is(err, nil), true)
+	shouldEqual(t, failure.Is(err, TestCodeA), false)
+
+	err = failure.Translate(failure.New(TestCodeA), nil)
+	_, ok = failure.CodeOf(err)
+	shouldEqual(t, ok, false)
+	shouldEqual(t, failure.Is(err, TestCodeA), false)
+
+	err = failure.New((*pointerCode)(nil))
+	shouldEqual(t, err.Error(), "failure_test.TestNilCode: code(%!code(*failure_test.pointerCode=<nil>))")
+}
+
+func TestCodeMapKey(t *testing.T) {
+	defer failure.SetDefaultPublicMessage(versionedCode{"a", 1}, "")
+
+	failure.SetDefaultPublicMessage(versionedCode{"a", 1}, "public")
+	msg, ok := failure.PublicMessageOf(failure.New(versionedCode{"a", 2}))
+	shouldEqual(t, ok, true)
+	shouldEqual(t, msg, "public")
+
+	defer func() {
+		shouldEqual(t, recover(), "failure: SetDefaultPublicMessage: code of type failure_test.sliceCode is not comparable by == operator")
+	}()
+	failure.SetDefaultPublicMessage(sliceCode{"a"}, "public")
+}
+
+type anyCode struct {
+	value interface{}
+}
+
+func (c anyCode) ErrorCode() string {
+	return fmt.Sprint(c.value)
+}
+
+func TestEqualCode_IncomparableValue(t *testing.T) {
+	// anyCode is a comparable type, but == panics for slices in the field.
+	shouldEqual(t, failure.EqualCode(anyCode{[]int{1}}, anyCode{[]int{1}}), true)
+	shouldEqual(t, failure.EqualCode(anyCode{[]int{1}}, anyCode{[]int{2}}), false)
+	shouldEqual(t, failure.EqualCode(anyCode{1}, anyCode{1}), true)
+	shouldEqual(t, failure.EqualCode(anyCode{1}, anyCode{[]int{1}}), false)
+	shouldEqual(t, failure.Is(failure.New(anyCode{[]int{1}}), anyCode{[]int{1}}), true)
+
+	failure.SetDefaultPublicMessage(anyCode{1}, "public")
+	defer failure.SetDefaultPublicMessage(anyCode{1}, "")
+	msg, ok := failure.PublicMessageOf(failure.New(anyCode{[]int{1}}))
+	shouldEqual(t, ok, false)
+	shouldEqual(t, msg, "")
+
+	defer func() {
+		shouldEqual(t, recover(), "failure: SetDefaultPublicMessage: code of type failure_test.anyCode is not comparable by == operator")
+	}()
+	failure.SetDefaultPublicMessage(anyCode{[]int{1}}, "public")
+}
@@ -0,0 +1,246 @@
+// Package codes provides canonical error codes for the failure package
+// modelled on the status codes of gRPC and Google APIs.
+//
+// The codes are not registered to failure.DefaultCodeRegistry by importing
+// the package, since their names (e.g. NOT_FOUND) may conflict with codes
+// of the program. Pass Resolver to failure.Unmarshal, or call Register to
+// opt in, so that they are reconstructed.
+package codes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/morikuni/failure"
+)
+
+var (
+	_ failure.CodeResolver   = Resolver
+	_ failure.RetryableCode  = Code(0)
+	_ failure.SeverityCode   = Code(0)
+	_ failure.HTTPStatusCode = Code(0)
+)
+
+// Code is a canonical error code.
+// It implements failure.RetryableCode, failure.SeverityCode and
+// failure.HTTPStatusCode.
+// It also implements the error interface to be used as a target of
+// errors.Is.
+type Code int
+
+// Canonical codes. The values are the same as gRPC.
+const (
+	// Canceled indicates the operation was canceled, typically by the caller.
+	Canceled Code = 1
+	// Unknown indicates an unknown error.
+	Unknown Code = 2
+	// InvalidArgument indicates the client specified an invalid argument.
+	InvalidArgument Code = 3
+	// DeadlineExceeded indicates the deadline expired before the operation
+	// could complete.
+	DeadlineExceeded Code = 4
+	// NotFound indicates some requested entity was not found.
+	NotFound Code = 5
+	// AlreadyExists indicates the entity that a client attempted to create
+	// already exists.
+	AlreadyExists Code = 6
+	// PermissionDenied indicates the caller does not have permission to
+	// execute the operation.
+	PermissionDenied Code = 7
+	// ResourceExhausted indicates some resource has been exhausted (e.g.
+	// rate limit).
+	ResourceExhausted Code = 8
+	// FailedPrecondition indicates the system is not in a state required
+	// for the operation.
+	FailedPrecondition Code = 9
+	// Aborted indicates the operation was aborted, typically due to a
+	// concurrency issue.
+	Aborted Code = 10
+	// OutOfRange indicates the operation was attempted past the valid range.
+	OutOfRange Code = 11
+	// Unimplemented indicates the operation is not implemented.
+	Unimplemented Code = 12
+	// Internal indicates an internal error.
+	Internal Code = 13
+	// Unavailable indicates the service is currently unavailable.
+	Unavailable Code = 14
+	// DataLoss indicates unrecoverable data loss or corruption.
+	DataLoss Code = 15
+	// Unauthenticated indicates the request does not have valid
+	// authentication credentials.
+	Unauthenticated Code = 16
+)
+
+type metadata struct {
+	name       string
+	httpStatus int
+	retryable  bool
+	severity   failure.Severity
+}
+
+var codes = map[Code]metadata{
+	Canceled:           {"CANCELLED", 499, false, failure.SeverityInfo},
+	Unknown:            {"UNKNOWN", http.StatusInternalServerError, false, failure.SeverityError},
+	InvalidArgument:    {"INVALID_ARGUMENT", http.StatusBadRequest, false, failure.SeverityInfo},
+	DeadlineExceeded:   {"DEADLINE_EXCEEDED", http.StatusGatewayTimeout, false, failure.SeverityWarning},
+	NotFound:           {"NOT_FOUND", http.StatusNotFound, false, failure.SeverityInfo},
+	AlreadyExists:      {"ALREADY_EXISTS", http.StatusConflict, false, failure.SeverityInfo},
+	PermissionDenied:   {"PERMISSION_DENIED", http.StatusForbidden, false, failure.SeverityInfo},
+	ResourceExhausted:  {"RESOURCE_EXHAUSTED", http.StatusTooManyRequests, true, failure.SeverityWarning},
+	FailedPrecondition: {"FAILED_PRECONDITION", http.StatusBadRequest, false, failure.SeverityInfo},
+	Aborted:            {"ABORTED", http.StatusConflict, true, failure.SeverityWarning},
+	OutOfRange:         {"OUT_OF_RANGE", http.StatusBadRequest, false, failure.SeverityInfo},
+	Unimplemented:      {"UNIMPLEMENTED", http.StatusNotImplemented, false, failure.SeverityError},
+	Internal:           {"INTERNAL", http.StatusInternalServerError, false, failure.SeverityError},
+	Unavailable:        {"UNAVAILABLE", http.StatusServiceUnavailable, true, failure.SeverityError},
+	DataLoss:           {"DATA_LOSS", http.StatusInternalServerError, false, failure.SeverityCritical},
+	Unauthenticated:    {"UNAUTHENTICATED", http.StatusUnauthorized, false, failure.SeverityInfo},
+}
+
+// All returns all canonical codes in the order of their values.
+func All() []Code {
+	all := make([]Code, 0, len(codes))
+	for c := Canceled; c <= Unauthenticated; c++ {
+		all = append(all, c)
+	}
+	return all
+}
+
+// Resolver is a failure.CodeResolver which resolves the canonical codes
+// by their names. Other names are not resolved.
+//
+//	var err error
+//	e := failure.Unmarshal(data, &err, codes.Resolver)
+var Resolver = failure.CodeResolverFunc(func(s string) (failure.Code, bool) {
+	for c, m := range codes {
+		if m.name == s {
+			return c, true
+		}
+	}
+	return nil, false
+})
+
+// Register registers the canonical codes to failure.DefaultCodeRegistry.
+// It returns an error without registering any code if a code of the same
+// name is already registered from another package.
+// It can be called more than once.
+func Register() error {
+	all := All()
+	fcs := make([]failure.Code, len(all))
+	for i, c := range all {
+		fcs[i] = c
+	}
+	return failure.DefaultCodeRegistry.Register(fcs...)
+}
+
+// ErrorCode implements the failure.Code interface.
+// It returns the name of the code in Google APIs (e.g. NOT_FOUND).
+func (c Code) ErrorCode() string {
+	if m, ok := codes[c]; ok {
+		return m.name
+	}
+	return "CODE(" + strconv.Itoa(int(c)) + ")"
+}
+
+// Error implements the error interface.
+func (c Code) Error() string {
+	return c.ErrorCode()
+}
+
+// String implements the fmt.Stringer interface.
+func (c Code) String() string {
+	return c.ErrorCode()
+}
+
+// Severity returns the severity of the code. Errors caused by clients
+// (e.g. NotFound) are SeverityInfo, and errors of servers (e.g. Internal)
+// are SeverityError.
+func (c Code) Severity() failure.Severity {
+	if m, ok := codes[c]; ok {
+		return m.severity
+	}
+	return failure.SeverityError
+}
+
+// Value returns the numeric value of the code, which is the same as gRPC.
+func (c Code) Value() int {
+	return int(c)
+}
+
+// HTTPStatus returns the HTTP status code mapped from the code.
+func (c Code) HTTPStatus() int {
+	if m, ok := codes[c]; ok {
+		return m.httpStatus
+	}
+	return http.StatusInternalServerError
+}
+
+// Retryable reports whether the operation may succeed by retrying,
+// typically with backoff. Unavailable, ResourceExhausted and Aborted are
+// retryable.
+func (c Code) Retryable() bool {
+	return codes[c].retryable
+}
+
+// FromError returns the canonical code for the err.
+// If the err has a canonical code by failure.CodeOf, the code is returned.
+// Otherwise, the standard sentinel errors in the err are translated.
+//
+//	context.Canceled         -> Canceled
+//	context.DeadlineExceeded -> DeadlineExceeded
+//	Timeout() bool is true   -> DeadlineExceeded (e.g. os.ErrDeadlineExceeded)
+//	os.ErrNotExist           -> NotFound (also fs.ErrNotExist)
+//	os.ErrExist              -> AlreadyExists (also fs.ErrExist)
+//	os.ErrPermission         -> PermissionDenied (also fs.ErrPermission)
+//	sql.ErrNoRows            -> NotFound
+//	sql.ErrTxDone            -> FailedPrecondition
+//	sql.ErrConnDone          -> Unavailable
+//	strconv.ErrSyntax        -> InvalidArgument
+//	strconv.ErrRange         -> OutOfRange
+//
+// It returns false if the err is not any of them.
+//
+//	if c, ok := codes.FromError(err); ok {
+//		return failure.Translate(err, c)
+//	}
+func FromError(err error) (Code, bool) {
+	if err == nil {
+		return 0, false
+	}
+	if fc, ok := failure.CodeOf(err); ok {
+		if c, ok := fc.(Code); ok {
+			return c, true
+		}
+	}
+
+	for _, s := range sentinels {
+		if errors.Is(err, s.err) {
+			return s.code, true
+		}
+	}
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return DeadlineExceeded, true
+	}
+	return 0, false
+}
+
+var sentinels = []struct {
+	err  error
+	code Code
+}{
+	{context.Canceled, Canceled},
+	{context.DeadlineExceeded, DeadlineExceeded},
+	{os.ErrNotExist, NotFound},
+	{os.ErrExist, AlreadyExists},
+	{os.ErrPermission, PermissionDenied},
+	{sql.ErrNoRows, NotFound},
+	{sql.ErrTxDone, FailedPrecondition},
+	{sql.ErrConnDone, Unavailable},
+	{strconv.ErrSyntax, InvalidArgument},
+	{strconv.ErrRange, OutOfRange},
+}
@@ -0,0 +1,122 @@
+package codes_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/morikuni/failure"
+	"github.com/morikuni/failure/codes"
+)
+
+func TestCode(t *testing.T) {
+	shouldEqual(t, codes.NotFound.ErrorCode(), "NOT_FOUND")
+	shouldEqual(t, codes.NotFound.Value(), 5)
+	shouldEqual(t, codes.NotFound.HTTPStatus(), http.StatusNotFound)
+	shouldEqual(t, codes.NotFound.Retryable(), false)
+	shouldEqual(t, codes.Unavailable.Retryable(), true)
+	shouldEqual(t, codes.Canceled.HTTPStatus(), 499)
+	shouldEqual(t, codes.Code(100).ErrorCode(), "CODE(100)")
+	shouldEqual(t, codes.Code(100).HTTPStatus(), http.StatusInternalServerError)
+
+	all := codes.All()
+	shouldEqual(t, len(all), 16)
+	for i, c := range all {
+		shouldEqual(t, c.Value(), i+1)
+		rc, ok := codes.Resolver.ResolveCode(c.ErrorCode())
+		shouldEqual(t, ok, true)
+		shouldEqual(t, rc, c)
+	}
+
+	err := failure.New(codes.NotFound)
+	shouldEqual(t, errors.Is(err, codes.NotFound), true)
+	shouldEqual(t, errors.Is(err, codes.Internal), false)
+
+	b, e := failure.Marshal(err)
+	shouldEqual(t, e, nil)
+	var decoded error
+	shouldEqual(t, failure.Unmarshal(b, &decoded, codes.Resolver), nil)
+	c, _ := failure.CodeOf(decoded)
+	shouldEqual(t, c, codes.NotFound)
+
+	_, ok := codes.Resolver.ResolveCode("CODE(100)")
+	shouldEqual(t, ok, false)
+}
+
+func TestRegister(t *testing.T) {
+	// Importing the package does not register the codes.
+	_, ok := failure.LookupCode("NOT_FOUND")
+	shouldEqual(t, ok, false)
+
+	shouldEqual(t, codes.Register(), nil)
+	shouldEqual(t, codes.Register(), nil)
+	c, ok := failure.LookupCode("NOT_FOUND")
+	shouldEqual(t, ok, true)
+	shouldEqual(t, c, codes.NotFound)
+
+	reg := failure.NewCodeRegistry()
+	shouldEqual(t, reg.Register(failure.StringCode("NOT_FOUND")), nil)
+	var decoded error
+	b, _ := failure.Marshal(failure.New(codes.NotFound))
+	shouldEqual(t, failure.Unmarshal(b, &decoded, reg), nil)
+	c, _ = failure.CodeOf(decoded)
+	shouldEqual(t, c, failure.StringCode("NOT_FOUND"))
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "timeout" }
+func (timeoutError) Timeout() bool { return true }
+
+func TestFromError(t *testing.T) {
+	_, parseErr := strconv.Atoi("x")
+
+	tests := map[string]struct {
+		err      error
+		wantCode codes.Code
+		wantOK   bool
+	}{
+		"canceled":          {context.Canceled, codes.Canceled, true},
+		"deadline":          {fmt.Errorf("query: %w", context.DeadlineExceeded), codes.DeadlineExceeded, true},
+		"timeout":           {timeoutError{}, codes.DeadlineExceeded, true},
+		"not exist":         {&os.PathError{Op: "open", Path: "x", Err: os.ErrNotExist}, codes.NotFound, true},
+		"exist":             {os.ErrExist, codes.AlreadyExists, true},
+		"permission":        {os.ErrPermission, codes.PermissionDenied, true},
+		"no rows":           {sql.ErrNoRows, codes.NotFound, true},
+		"tx done":           {sql.ErrTxDone, codes.FailedPrecondition, true},
+		"conn done":         {sql.ErrConnDone, codes.Unavailable, true},
+		"syntax":            {parseErr, codes.InvalidArgument, true},
+		"canonical code":    {failure.Translate(sql.ErrNoRows, codes.PermissionDenied), codes.PermissionDenied, true},
+		"other code":        {failure.Translate(sql.ErrNoRows, failure.StringCode("x")), codes.NotFound, true},
+		"unknown":           {io.EOF, 0, false},
+		"nil":               {nil, 0, false},
+		"unexpected":        {failure.Unexpected("x"), 0, false},
+		"unexpected cancel": {failure.MarkUnexpected(context.Canceled), codes.Canceled, true},
+	}
+
+	for title, test := range tests {
+		t.Run(title, func(t *testing.T) {
+			c, ok := codes.FromError(test.err)
+			shouldEqual(t, c, test.wantCode)
+			shouldEqual(t, ok, test.wantOK)
+		})
+	}
+}
+
+func TestCode_Metadata(t *testing.T) {
+	err := failure.New(codes.Unavailable)
+	shouldEqual(t, failure.IsRetryable(err), true)
+	s, _ := failure.SeverityOf(err)
+	shouldEqual(t, s, failure.SeverityError)
+	status, _ := failure.HTTPStatusOf(err)
+	shouldEqual(t, status, http.StatusServiceUnavailable)
+
+	s, _ = failure.SeverityOf(failure.New(codes.NotFound))
+	shouldEqual(t, s, failure.SeverityInfo)
+}
@@ -3,56 +3,166 @@
 package failure
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 )
 
 // CodeOf extracts an error code from the err.
 // The first error code found in the err is returned, but if Unexpected
 // interface is detected before the code is found, it behaves as if
 // there is no code found.
+// A nil code (e.g. failure.New(nil)) is not a code, and it hides inner
+// codes like Unexpected, thus CodeOf returns (nil, false) for it.
+//
+// When the err contains multiple errors (e.g. errors.Join), branches are
+// searched in depth-first order from left to right and the first code found
+// is returned. An Unexpected error only hides codes in its own branch, so a
+// code in a sibling branch is still returned.
+// Use CodesOf to get the codes of all branches.
+//
+// errors.As with *Code finds the same code as CodeOf, and errors.Is
+// with a code reports whether the code or its descendant is in CodesOf.
+// To keep them in agreement, errors.Unwrap of an error with a code or of
+// an Unexpected error returns a view of the wrapped error hiding its
+// codes, not the wrapped error itself. The view still matches the wrapped
+// error and non-code targets by errors.Is and errors.As.
 func CodeOf(err error) (Code, bool) {
 	if err == nil {
 		return nil, false
 	}
+	return nextCode(NewIterator(err))
+}
+
+// CodesOf extracts error codes from all branches of the err.
+// Each branch contributes the code which CodeOf would return for the branch,
+// thus codes overwritten by Translate and codes hidden by Unexpected
+// are not included. The codes are ordered in depth-first order.
+// Use CodeHistoryOf to get all codes including such codes.
+func CodesOf(err error) []Code {
+	if err == nil {
+		return nil
+	}
 
+	var codes []Code
 	i := NewIterator(err)
+	for {
+		c, ok := nextCode(i)
+		if !ok {
+			return codes
+		}
+		codes = append(codes, c)
+	}
+}
+
+// nextCode advances the iterator to the next error code which is not
+// hidden by Unexpected or another code.
+func nextCode(i *Iterator) (Code, bool) {
 	for i.Next() {
-		if v, ok := i.Error().(interface{ Unexpected() bool }); ok && v.Unexpected() {
-			return nil, false
+		if isUnexpected(i.Error()) {
+			i.skipUnderlying()
+			continue
 		}
 
 		var c Code
 		if i.As(&c) {
+			i.skipUnderlying()
+			if c == nil {
+				// A nil code (e.g. New(nil)) removes the code.
+				continue
+			}
 			return c, true
 		}
 	}
-
 	return nil, false
 }
 
 // New creates an error from error code.
 func New(code Code, wrappers ...Wrapper) error {
-	return Custom(Custom(&withCode{code: code}, wrappers...), WithFormatter(), WithCallStackSkip(1))
+	wrappers, opts := splitOptions(wrappers)
+	return finish(Custom(&withCode{code: code}, wrappers...), 1, code, true, opts)
 }
 
 // Translate translates the err to an error with given code.
 // It wraps the error with given wrappers, and automatically
 // add call stack and formatter.
 func Translate(err error, code Code, wrappers ...Wrapper) error {
-	return Custom(Custom(Custom(err, WithCode(code)), wrappers...), WithFormatter(), WithCallStackSkip(1))
+	if err == nil {
+		return nil
+	}
+	wrappers, opts := splitOptions(wrappers)
+	return finish(Custom(Custom(err, WithCode(code)), wrappers...), 1, code, true, opts)
 }
 
 // Wrap wraps err with given wrappers, and automatically add
 // call stack and formatter.
 func Wrap(err error, wrappers ...Wrapper) error {
-	return Custom(Custom(err, wrappers...), WithFormatter(), WithCallStackSkip(1))
+	if err == nil {
+		return nil
+	}
+	wrappers, opts := splitOptions(wrappers)
+	return finish(Custom(err, wrappers...), 1, nil, false, opts)
 }
 
 // MarkUnexpected wraps err and preventing propagation of error code from underlying error.
 // It is used where an error can be returned but expecting it does not happen.
 // The returned error does not return error code from function CodeOf.
 func MarkUnexpected(err error, wrappers ...Wrapper) error {
-	return Custom(Custom(Custom(err, WithUnexpected()), wrappers...), WithFormatter(), WithCallStackSkip(1))
+	if err == nil {
+		return nil
+	}
+	wrappers, opts := splitOptions(wrappers)
+	return finish(Custom(Custom(err, WithUnexpected()), wrappers...), 1, nil, true, opts)
+}
+
+// finish appends the call stack of the caller of the constructor function
+// skipping first N frames, and formatter to the err.
+// The call stack is captured according to the CallStackPolicy for the code.
+// If codeKnown is false, the code is extracted from the err if needed.
+func finish(err error, skip int, code Code, codeKnown bool, opts options) error {
+	if err == nil {
+		return nil
+	}
+
+	if shouldCaptureCallStack(err, code, codeKnown) {
+		var cs CallStack
+		if opts.hasDepth {
+			cs = CallersDepth(skip+1, opts.depth)
+		} else {
+			cs = Callers(skip + 1)
+		}
+		err = &withCallStack{cs, err}
+	}
+	return &formatter{err}
+}
+
+// options are options of constructor functions given as Wrappers.
+type options struct {
+	depth    int
+	hasDepth bool
+}
+
+// splitOptions separates options (e.g. CallStackDepth) from the wrappers.
+func splitOptions(wrappers []Wrapper) ([]Wrapper, options) {
+	var opts options
+	n := 0
+	for _, w := range wrappers {
+		if d, ok := w.(CallStackDepth); ok {
+			opts.depth, opts.hasDepth = int(d), true
+			n++
+		}
+	}
+	if n == 0 {
+		return wrappers, opts
+	}
+
+	ws := make([]Wrapper, 0, len(wrappers)-n)
+	for _, w := range wrappers {
+		if _, ok := w.(CallStackDepth); !ok {
+			ws = append(ws, w)
+		}
+	}
+	return ws, opts
 }
 
 // Custom is the general error wrapping constructor.
@@ -69,6 +179,13 @@ func Custom(err error, wrappers ...Wrapper) error {
 	return err
 }
 
+// isUnexpected reports whether the err itself is marked unexpected.
+// Errors wrapped by the err are not checked.
+func isUnexpected(err error) bool {
+	v, ok := err.(interface{ Unexpected() bool })
+	return ok && v.Unexpected()
+}
+
 type unexpected string
 
 func (e unexpected) Error() string {
@@ -92,7 +209,8 @@ func (e unexpected) As(x interface{}) bool {
 // Unexpected creates an error from message without error code.
 // The returned error should be kind of internal or unknown error.
 func Unexpected(msg string, wrappers ...Wrapper) error {
-	return Custom(Custom(unexpected(msg), wrappers...), WithFormatter(), WithCallStackSkip(1))
+	wrappers, opts := splitOptions(wrappers)
+	return finish(Custom(unexpected(msg), wrappers...), 1, nil, true, opts)
 }
 
 // WithCode appends code to an error.
@@ -110,7 +228,7 @@ type withCode struct {
 }
 
 func (w *withCode) Unwrap() error {
-	return w.underlying
+	return hideCode(w.underlying)
 }
 
 // Deprecated: This function will be deleted in v1.0.0 release. Please use As method on Iterator.
@@ -127,13 +245,109 @@ func (w *withCode) As(x interface{}) bool {
 		(*t).Push(w.code)
 		return true
 	default:
-		return false
+		return asCode(w.code, x)
 	}
 }
 
+// Is implements the interface for errors.Is.
+// It reports whether the target is the code of the error or its ancestor.
+func (w *withCode) Is(target error) bool {
+	c, ok := target.(Code)
+	return ok && CodeIs(w.code, c)
+}
+
 func (w *withCode) Error() string {
 	if w.underlying == nil {
-		return fmt.Sprintf("code(%s)", w.code.ErrorCode())
+		return fmt.Sprintf("code(%s)", codeString(w.code))
+	}
+	return fmt.Sprintf("code(%s): %s", codeString(w.code), w.underlying)
+}
+
+var codeType = reflect.TypeOf((*Code)(nil)).Elem()
+
+// isCodeTarget reports whether x is a pointer to be filled with a code
+// (e.g. *Code, *StringCode).
+func isCodeTarget(x interface{}) bool {
+	t := reflect.TypeOf(x)
+	return t != nil && t.Kind() == reflect.Ptr && t.Elem().Implements(codeType)
+}
+
+// asCode sets the code to x if x is a pointer to the type of the code or
+// a pointer to an interface which embeds Code.
+func asCode(code Code, x interface{}) bool {
+	if !isCodeTarget(x) {
+		return false
+	}
+	v := reflect.ValueOf(x)
+	if v.IsNil() || code == nil || !reflect.TypeOf(code).AssignableTo(v.Type().Elem()) {
+		return false
+	}
+	v.Elem().Set(reflect.ValueOf(code))
+	return true
+}
+
+// hideCode returns a view of the err for errors.Is and errors.As which
+// does not match any code.
+// Wrappers which stop propagation of codes from the underlying error
+// (e.g. withCode, withUnexpected) return it from Unwrap, so that errors.Is
+// and errors.As find the same codes as CodeOf and CodesOf.
+// Iterator sees through the view.
+func hideCode(err error) error {
+	switch err.(type) {
+	case nil:
+		return nil
+	case interface{ Unwrap() []error }:
+		return &multiCodeHider{codeHider{err}}
+	default:
+		return &codeHider{err}
+	}
+}
+
+type codeHider struct {
+	error
+}
+
+func (h *codeHider) Unwrap() error {
+	return hideCode(errors.Unwrap(h.error))
+}
+
+func (h *codeHider) Is(target error) bool {
+	if _, ok := target.(Code); ok {
+		return false
+	}
+	if target != nil && reflect.TypeOf(target).Comparable() && h.error == target {
+		return true
+	}
+	if x, ok := h.error.(interface{ Is(error) bool }); ok {
+		return x.Is(target)
+	}
+	return false
+}
+
+func (h *codeHider) As(x interface{}) bool {
+	if isCodeTarget(x) {
+		return false
+	}
+	if v := reflect.ValueOf(x); v.Kind() == reflect.Ptr && !v.IsNil() &&
+		reflect.TypeOf(h.error).AssignableTo(v.Type().Elem()) {
+		v.Elem().Set(reflect.ValueOf(h.error))
+		return true
+	}
+	if t, ok := h.error.(interface{ As(interface{}) bool }); ok {
+		return t.As(x)
+	}
+	return false
+}
+
+type multiCodeHider struct {
+	codeHider
+}
+
+func (h *multiCodeHider) Unwrap() []error {
+	errs := h.error.(interface{ Unwrap() []error }).Unwrap()
+	hidden := make([]error, len(errs))
+	for i, err := range errs {
+		hidden[i] = hideCode(err)
 	}
-	return fmt.Sprintf("code(%s): %s", w.code.ErrorCode(), w.underlying)
+	return hidden
 }
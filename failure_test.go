@@ -222,3 +222,62 @@ func TestFailure(t *testing.T) {
 		})
 	}
 }
+
+func TestCallStackPolicy(t *testing.T) {
+	defer failure.SetCallStackPolicy(nil)
+	defer failure.SetCodeCallStackPolicy(TestCodeA, nil)
+
+	hasCallStack := func(err error) bool {
+		_, ok := failure.CallStackOf(err)
+		return ok
+	}
+
+	failure.SetCallStackPolicy(failure.CaptureNever)
+	err := failure.New(TestCodeA, failure.Message("xxx"))
+	shouldEqual(t, hasCallStack(err), false)
+	shouldEqual(t, err.Error(), "xxx: code(code_a)")
+	shouldMatch(t, fmt.Sprintf("%+v", err), `^    message\("xxx"\)\n    code\(code_a\)\n\[CallStack\]\n$`)
+
+	failure.SetCallStackPolicy(failure.CaptureUnexpected)
+	shouldEqual(t, hasCallStack(failure.New(TestCodeA)), false)
+	shouldEqual(t, hasCallStack(failure.Wrap(failure.New(TestCodeA))), false)
+	shouldEqual(t, hasCallStack(failure.Wrap(io.EOF)), true)
+	shouldEqual(t, hasCallStack(failure.Unexpected("xxx")), true)
+	shouldEqual(t, hasCallStack(failure.MarkUnexpected(failure.New(TestCodeA))), true)
+
+	failure.SetCodeCallStackPolicy(TestCodeA, failure.CaptureAlways)
+	shouldEqual(t, hasCallStack(failure.New(TestCodeA)), true)
+	shouldEqual(t, hasCallStack(failure.Translate(io.EOF, TestCodeB)), false)
+
+	failure.SetCallStackPolicy(failure.CaptureSampled(0))
+	failure.SetCodeCallStackPolicy(TestCodeA, failure.CaptureSampled(1))
+	shouldEqual(t, hasCallStack(failure.New(TestCodeA)), true)
+	shouldEqual(t, hasCallStack(failure.New(TestCodeB)), false)
+
+	failure.SetCallStackPolicy(nil)
+	failure.SetCodeCallStackPolicy(TestCodeA, nil)
+	shouldEqual(t, hasCallStack(failure.New(TestCodeB)), true)
+	shouldEqual(t, failure.Wrap(nil), nil)
+	shouldEqual(t, failure.Translate(nil, TestCodeA), nil)
+	shouldEqual(t, failure.MarkUnexpected(nil), nil)
+}
+
+func BenchmarkCallStackPolicy(b *testing.B) {
+	policies := map[string]failure.CallStackPolicy{
+		"always":     failure.CaptureAlways,
+		"never":      failure.CaptureNever,
+		"sampled":    failure.CaptureSampled(0.01),
+		"unexpected": failure.CaptureUnexpected,
+	}
+	for name, p := range policies {
+		b.Run(name, func(b *testing.B) {
+			failure.SetCallStackPolicy(p)
+			defer failure.SetCallStackPolicy(nil)
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				failure.Wrap(failure.New(TestCodeA))
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package failure
+
+// CodeAs returns the code of the err if it is of type T.
+// It is useful to extract the data of PayloadCode.
+//
+//	if rl, ok := failure.CodeAs[RateLimited](err); ok {
+//		time.Sleep(rl.RetryAfter)
+//	}
+func CodeAs[T Code](err error) (T, bool) {
+	c, ok := CodeOf(err)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	t, ok := c.(T)
+	return t, ok
+}
+
+// CodeOfType returns the first code of type T in the codes from CodesOf.
+// Unlike CodeAs, it also looks for codes in the other branches of the err.
+//
+//	code, ok := failure.CodeOfType[user.ErrorCode](err)
+func CodeOfType[T Code](err error) (T, bool) {
+	for _, c := range CodesOf(err) {
+		if t, ok := c.(T); ok {
+			return t, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// First returns the first value of type T in the err.
+// Values are searched in the same order as Iterator, and extracted by the
+// As method of each error or type assertion of the error itself. So, T can
+// be a type of wrappers (e.g. Message, Context, CallStack), a type of codes,
+// or a type of errors.
+// Like CodeOf, errors wrapped by Unexpected error are not searched.
+//
+//	ctx, ok := failure.First[failure.Context](err)
+func First[T any](err error) (T, bool) {
+	var (
+		first T
+		found bool
+	)
+	eachValue(err, func(v T) bool {
+		first, found = v, true
+		return false
+	})
+	return first, found
+}
+
+// FindAll returns all values of type T in the err in the same order as
+// Iterator. See First for how values are searched.
+//
+//	msgs := failure.FindAll[failure.Message](err)
+func FindAll[T any](err error) []T {
+	var all []T
+	eachValue(err, func(v T) bool {
+		all = append(all, v)
+		return true
+	})
+	return all
+}
+
+// eachValue calls f with values of type T in the err until f returns false.
+func eachValue[T any](err error, f func(T) bool) {
+	if err == nil {
+		return
+	}
+
+	i := NewIterator(err)
+	for i.Next() {
+		var v T
+		if i.As(&v) {
+			if !f(v) {
+				return
+			}
+		} else if t, ok := i.Error().(T); ok {
+			if !f(t) {
+				return
+			}
+		}
+		if isUnexpected(i.Error()) {
+			// Errors inside of the unexpected error are hidden like CodeOf.
+			i.skipUnderlying()
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package failure_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/morikuni/failure"
+)
+
+func TestValueAs(t *testing.T) {
+	err := failure.New(TestCodeA, failure.TypedContext{"elapsed": time.Second}, failure.Context{"name": "x"})
+
+	d, ok := failure.ValueAs[time.Duration](err, "elapsed")
+	shouldEqual(t, ok, true)
+	shouldEqual(t, d, time.Second)
+
+	s, ok := failure.ValueAs[string](err, "name")
+	shouldEqual(t, ok, true)
+	shouldEqual(t, s, "x")
+
+	_, ok = failure.ValueAs[int](err, "elapsed")
+	shouldEqual(t, ok, false)
+	_, ok = failure.ValueAs[int](err, "unknown")
+	shouldEqual(t, ok, false)
+}
+
+func TestCodeAs(t *testing.T) {
+	err := failure.Wrap(failure.New(RateLimited{RetryAfter: time.Second}))
+
+	rl, ok := failure.CodeAs[RateLimited](err)
+	shouldEqual(t, ok, true)
+	shouldEqual(t, rl.RetryAfter, time.Second)
+
+	_, ok = failure.CodeAs[failure.StringCode](err)
+	shouldEqual(t, ok, false)
+	_, ok = failure.CodeAs[RateLimited](failure.Unexpected("x"))
+	shouldEqual(t, ok, false)
+}
+
+func TestCodeOfType(t *testing.T) {
+	err := errors.Join(
+		failure.New(TestCodeA),
+		failure.New(RateLimited{RetryAfter: time.Second}),
+		failure.MarkUnexpected(failure.New(RateLimited{RetryAfter: time.Minute})),
+	)
+
+	rl, ok := failure.CodeOfType[RateLimited](err)
+	shouldEqual(t, ok, true)
+	shouldEqual(t, rl.RetryAfter, time.Second)
+
+	_, ok = failure.CodeAs[RateLimited](err)
+	shouldEqual(t, ok, false)
+
+	_, ok = failure.CodeOfType[RateLimited](failure.MarkUnexpected(failure.New(RateLimited{})))
+	shouldEqual(t, ok, false)
+}
+
+func TestFirst(t *testing.T) {
+	err := failure.Translate(
+		failure.New(TestCodeA, failure.Message("inner"), failure.Context{"a": "1"}),
+		TestCodeB,
+		failure.Message("outer"),
+	)
+
+	msg, ok := failure.First[failure.Message](err)
+	shouldEqual(t, ok, true)
+	shouldEqual(t, msg, failure.Message("outer"))
+
+	ctx, ok := failure.First[failure.Context](err)
+	shouldEqual(t, ok, true)
+	shouldEqual(t, ctx, failure.Context{"a": "1"})
+
+	code, ok := failure.First[failure.Code](err)
+	shouldEqual(t, ok, true)
+	shouldEqual(t, code, TestCodeB)
+
+	pathErr := &os.PathError{Op: "open", Path: "x", Err: io.EOF}
+	pe, ok := failure.First[*os.PathError](failure.Wrap(pathErr))
+	shouldEqual(t, ok, true)
+	shouldEqual(t, pe, pathErr)
+
+	_, ok = failure.First[failure.Code](failure.MarkUnexpected(failure.New(TestCodeA)))
+	shouldEqual(t, ok, false)
+	_, ok = failure.First[failure.Message](nil)
+	shouldEqual(t, ok, false)
+}
+
+func TestFindAll(t *testing.T) {
+	const C failure.StringCode = "code_c"
+
+	err := errors.Join(
+		failure.Translate(failure.New(TestCodeA, failure.Message("a")), TestCodeB, failure.Message("b")),
+		failure.MarkUnexpected(failure.New(TestCodeA, failure.Message("hidden"))),
+		failure.New(C, failure.Message("c")),
+	)
+
+	shouldEqual(t, failure.FindAll[failure.Message](err), []failure.Message{"b", "a", "c"})
+	shouldEqual(t, failure.FindAll[failure.Code](err), []failure.Code{TestCodeB, TestCodeA, C})
+	shouldEqual(t, failure.FindAll[failure.Context](err), []failure.Context(nil))
+	shouldEqual(t, failure.FindAll[failure.Message](nil), []failure.Message(nil))
+}
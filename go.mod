@@ -1,3 +1,3 @@
 module github.com/morikuni/failure
 
-go 1.13
+go 1.21
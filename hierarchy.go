@@ -0,0 +1,61 @@
+package failure
+
+// ChildCode is a Code which belongs to a family of the parent code.
+// Is and errors.Is with the parent code match errors of the child code,
+// and metadata of the code (e.g. HTTPStatusOf) falls back to the parent.
+type ChildCode interface {
+	Code
+	// Parent returns the parent code. nil means the code has no parent.
+	Parent() Code
+}
+
+// ParentOf returns the parent code of the code.
+// A nil pointer code has no parent.
+func ParentOf(code Code) (Code, bool) {
+	if cc, ok := code.(ChildCode); ok && !isNilPointerCode(cc) {
+		if p := cc.Parent(); p != nil {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// AncestorsOf returns the ancestors of the code from the parent to the
+// root. It stops at the code which appeared already to avoid infinite loop.
+func AncestorsOf(code Code) []Code {
+	var ancestors []Code
+	for {
+		p, ok := ParentOf(code)
+		if !ok || EqualCode(code, p) || containsCode(ancestors, p) {
+			return ancestors
+		}
+		ancestors = append(ancestors, p)
+		code = p
+	}
+}
+
+// CodeIs reports whether the code is the target or a descendant of the
+// target.
+func CodeIs(code, target Code) bool {
+	if EqualCode(code, target) {
+		return true
+	}
+	if target == nil {
+		return false
+	}
+	return containsCode(AncestorsOf(code), target)
+}
+
+func containsCode(codes []Code, code Code) bool {
+	for _, c := range codes {
+		if EqualCode(c, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// lineageOf returns the code and its ancestors.
+func lineageOf(code Code) []Code {
+	return append([]Code{code}, AncestorsOf(code)...)
+}
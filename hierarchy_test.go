@@ -0,0 +1,106 @@
+package failure_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/morikuni/failure"
+)
+
+var TestAuth = failure.DefineCode("auth").
+	WithHTTPStatus(http.StatusUnauthorized).
+	WithSeverity(failure.SeverityInfo)
+
+var (
+	TestAuthToken        = failure.DefineCode("auth.token").WithParent(TestAuth)
+	TestAuthTokenExpired = failure.DefineCode("auth.token_expired").WithParent(TestAuthToken).WithRetryable(true)
+	TestAuthTokenInvalid = failure.DefineCode("auth.token_invalid").WithParent(TestAuthToken).WithHTTPStatus(http.StatusForbidden)
+)
+
+func TestCodeHierarchy(t *testing.T) {
+	p, ok := failure.ParentOf(TestAuthTokenExpired)
+	shouldEqual(t, ok, true)
+	shouldEqual(t, p, failure.Code(TestAuthToken))
+	_, ok = failure.ParentOf(TestAuth)
+	shouldEqual(t, ok, false)
+	_, ok = failure.ParentOf(TestCodeA)
+	shouldEqual(t, ok, false)
+
+	shouldEqual(t, failure.AncestorsOf(TestAuthTokenExpired), []failure.Code{TestAuthToken, TestAuth})
+	shouldEqual(t, failure.AncestorsOf(TestCodeA), []failure.Code(nil))
+
+	shouldEqual(t, failure.CodeIs(TestAuthTokenExpired, TestAuth), true)
+	shouldEqual(t, failure.CodeIs(TestAuthTokenExpired, TestAuthTokenExpired), true)
+	shouldEqual(t, failure.CodeIs(TestAuth, TestAuthToken), false)
+	shouldEqual(t, failure.CodeIs(TestAuthTokenExpired, TestAuthTokenInvalid), false)
+	shouldEqual(t, failure.CodeIs(TestAuthTokenExpired, nil), false)
+
+	err := failure.New(TestAuthTokenExpired)
+	shouldEqual(t, failure.Is(err, TestAuth), true)
+	shouldEqual(t, failure.Is(err, TestAuthToken), true)
+	shouldEqual(t, failure.Is(err, TestAuthTokenInvalid), false)
+	shouldEqual(t, errors.Is(err, TestAuth), true)
+	shouldEqual(t, errors.Is(failure.New(TestAuth), TestAuthToken), false)
+	shouldEqual(t, failure.Is(failure.MarkUnexpected(err), TestAuth), false)
+}
+
+func TestCodeHierarchy_Cycle(t *testing.T) {
+	a := cyclicCode{"a", "b"}
+	shouldEqual(t, failure.AncestorsOf(a), []failure.Code{cyclicCode{"b", "a"}, a})
+	shouldEqual(t, failure.CodeIs(a, failure.StringCode("c")), false)
+}
+
+type cyclicCode struct {
+	code   string
+	parent string
+}
+
+func (c cyclicCode) ErrorCode() string {
+	return c.code
+}
+
+func (c cyclicCode) Parent() failure.Code {
+	return cyclicCode{c.parent, c.code}
+}
+
+func TestCodeHierarchy_Metadata(t *testing.T) {
+	err := failure.New(TestAuthTokenExpired)
+	shouldEqual(t, failure.IsRetryable(err), true)
+	s, _ := failure.HTTPStatusOf(err)
+	shouldEqual(t, s, http.StatusUnauthorized)
+	sev, _ := failure.SeverityOf(err)
+	shouldEqual(t, sev, failure.SeverityInfo)
+
+	err = failure.New(TestAuthTokenInvalid)
+	shouldEqual(t, failure.IsRetryable(err), false)
+	s, _ = failure.HTTPStatusOf(err)
+	shouldEqual(t, s, http.StatusForbidden)
+
+	shouldEqual(t, failure.IsRetryable(failure.New(failure.DefineCode("x").WithRetryable(false).WithParent(TestAuthTokenExpired))), false)
+	shouldEqual(t, failure.IsRetryable(failure.New(failure.DefineCode("x").WithParent(TestAuthTokenExpired))), true)
+
+	failure.SetDefaultPublicMessage(TestAuth, "Please sign in again.")
+	defer failure.SetDefaultPublicMessage(TestAuth, "")
+	msg, _ := failure.PublicMessageOf(err)
+	shouldEqual(t, msg, "Please sign in again.")
+}
+
+type valueChildCode struct {
+	parent failure.Code
+}
+
+func (c valueChildCode) ErrorCode() string    { return "child" }
+func (c valueChildCode) Parent() failure.Code { return c.parent }
+
+func TestParentOf_NilPointerCode(t *testing.T) {
+	_, ok := failure.ParentOf((*valueChildCode)(nil))
+	shouldEqual(t, ok, false)
+	shouldEqual(t, failure.Is(failure.New((*valueChildCode)(nil)), TestAuth), false)
+
+	_, err := failure.ErrorCodeOf((*valueChildCode)(nil))
+	shouldEqual(t, err.Error(), "failure: nil pointer code of type *failure_test.valueChildCode")
+	s, err := failure.ErrorCodeOf(valueChildCode{TestAuth})
+	shouldEqual(t, err, nil)
+	shouldEqual(t, s, "child")
+}
@@ -0,0 +1,130 @@
+package failure
+
+import "fmt"
+
+// CodeStatus is a status of a code in the history of codes.
+type CodeStatus int
+
+// Statuses of codes.
+const (
+	// CodeEffective is the status of codes returned by CodeOf or CodesOf.
+	CodeEffective CodeStatus = iota + 1
+	// CodeShadowed is the status of codes overwritten by an outer code
+	// (e.g. by Translate).
+	CodeShadowed
+	// CodeHidden is the status of codes hidden by Unexpected error.
+	CodeHidden
+)
+
+// String implements the fmt.Stringer interface.
+func (s CodeStatus) String() string {
+	switch s {
+	case CodeEffective:
+		return "effective"
+	case CodeShadowed:
+		return "shadowed"
+	case CodeHidden:
+		return "hidden"
+	}
+	return fmt.Sprintf("CodeStatus(%d)", int(s))
+}
+
+// CodeRecord is a code in the history of codes of an error.
+type CodeRecord struct {
+	// Code is the code.
+	Code Code
+	// Frame is the head frame of the call stack where the code is added
+	// (e.g. the caller of Translate).
+	// It is nil if the code is added without call stack.
+	Frame Frame
+	// Status is the status of the code.
+	Status CodeStatus
+	// Branch is the path of the branch of multiple errors which the code
+	// belongs to (e.g. "1.2") like Layer.Branch.
+	Branch string
+}
+
+// CodeHistoryOf returns all codes in the err in the order they are added,
+// that is from the innermost code to the outermost code.
+// For example, the history of the following error is
+// db.not_found (shadowed), user.not_found (shadowed) and
+// api.not_found (effective).
+//
+//	err := failure.New(db.NotFound)
+//	err = failure.Translate(err, user.NotFound)
+//	err = failure.Translate(err, api.NotFound)
+//
+// When the err contains multiple errors, codes in the branches come
+// first from left to right, and then codes wrapping the branches follow.
+//
+// Unlike CodesOf, which returns only the effective code of each branch,
+// it returns shadowed and hidden codes too with their statuses.
+func CodeHistoryOf(err error) []CodeRecord {
+	var (
+		history []CodeRecord
+		// stack holds records of each branch from outer to inner.
+		stack [][]CodeRecord
+		frame Frame
+	)
+	walkChain(err, chainVisitor{
+		enter: func(string) {
+			stack = append(stack, nil)
+			frame = nil
+		},
+		visit: func(n chainNode) {
+			i := &Iterator{err: n.err}
+			var (
+				cs   CallStack
+				code Code
+			)
+			switch {
+			case i.As(&cs):
+				frame = cs.HeadFrame()
+			case i.As(&code):
+				if code != nil {
+					top := len(stack) - 1
+					stack[top] = append(stack[top], CodeRecord{code, frame, n.scope.status(), n.branch})
+				}
+				frame = nil
+			}
+		},
+		leave: func(string) {
+			records := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for j := len(records) - 1; j >= 0; j-- {
+				history = append(history, records[j])
+			}
+		},
+	})
+	return history
+}
+
+// codeScope is the state of the chain from the top to an error, which
+// decides the status of codes in the error.
+type codeScope struct {
+	shadowed bool
+	hidden   bool
+}
+
+// status returns the status of a code in the scope.
+func (cs codeScope) status() CodeStatus {
+	switch {
+	case cs.hidden:
+		return CodeHidden
+	case cs.shadowed:
+		return CodeShadowed
+	default:
+		return CodeEffective
+	}
+}
+
+// enter returns the scope of errors wrapped by the err.
+func (cs codeScope) enter(err error, hasCode bool) codeScope {
+	if hasCode {
+		cs.shadowed = true
+	}
+	if isUnexpected(err) {
+		cs.hidden = true
+	}
+	return cs
+}
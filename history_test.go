@@ -0,0 +1,146 @@
+package failure_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/morikuni/failure"
+)
+
+const (
+	historyDB   failure.StringCode = "db.not_found"
+	historyUser failure.StringCode = "user.not_found"
+	historyAPI  failure.StringCode = "api.not_found"
+)
+
+func findInDB() error {
+	return failure.New(historyDB)
+}
+
+func findUser() error {
+	return failure.Translate(findInDB(), historyUser)
+}
+
+func handleAPI() error {
+	return failure.Translate(failure.Wrap(findUser()), historyAPI)
+}
+
+type codeRecord struct {
+	code   failure.Code
+	fn     string
+	status failure.CodeStatus
+	branch string
+}
+
+func simplifyHistory(history []failure.CodeRecord) []codeRecord {
+	var rs []codeRecord
+	for _, r := range history {
+		fn := ""
+		if r.Frame != nil {
+			fn = r.Frame.Func()
+		}
+		rs = append(rs, codeRecord{r.Code, fn, r.Status, r.Branch})
+	}
+	return rs
+}
+
+func TestCodeHistoryOf(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want []codeRecord
+	}{
+		"translate": {
+			err: handleAPI(),
+			want: []codeRecord{
+				{historyDB, "findInDB", failure.CodeShadowed, ""},
+				{historyUser, "findUser", failure.CodeShadowed, ""},
+				{historyAPI, "handleAPI", failure.CodeEffective, ""},
+			},
+		},
+		"unexpected": {
+			err: failure.Translate(failure.MarkUnexpected(findUser()), historyAPI),
+			want: []codeRecord{
+				{historyDB, "findInDB", failure.CodeHidden, ""},
+				{historyUser, "findUser", failure.CodeHidden, ""},
+				{historyAPI, "TestCodeHistoryOf", failure.CodeEffective, ""},
+			},
+		},
+		"join": {
+			err: failure.Wrap(errors.Join(findUser(), failure.MarkUnexpected(findInDB()))),
+			want: []codeRecord{
+				{historyDB, "findInDB", failure.CodeShadowed, "1"},
+				{historyUser, "findUser", failure.CodeEffective, "1"},
+				{historyDB, "findInDB", failure.CodeHidden, "2"},
+			},
+		},
+		"translate join": {
+			err: failure.Translate(errors.Join(findInDB()), historyAPI),
+			want: []codeRecord{
+				{historyDB, "findInDB", failure.CodeShadowed, ""},
+				{historyAPI, "TestCodeHistoryOf", failure.CodeEffective, ""},
+			},
+		},
+		"no call stack": {
+			err: failure.Custom(failure.Custom(errors.New("x"), failure.WithCode(historyDB))),
+			want: []codeRecord{
+				{historyDB, "", failure.CodeEffective, ""},
+			},
+		},
+		"nil": {
+			err:  nil,
+			want: nil,
+		},
+	}
+
+	for title, test := range tests {
+		t.Run(title, func(t *testing.T) {
+			shouldEqual(t, simplifyHistory(failure.CodeHistoryOf(test.err)), test.want)
+		})
+	}
+}
+
+func TestCodeHistoryOf_Format(t *testing.T) {
+	err := failure.Translate(failure.MarkUnexpected(findUser()), historyAPI)
+
+	out := fmt.Sprintf("%+v", err)
+	shouldContain(t, out, "    code(api.not_found)\n")
+	shouldContain(t, out, "    code(user.not_found) (hidden)\n")
+	shouldContain(t, out, "    code(db.not_found) (hidden)\n")
+
+	out = fmt.Sprintf("%+v", handleAPI())
+	shouldContain(t, out, "    code(api.not_found)\n")
+	shouldContain(t, out, "    code(user.not_found) (shadowed)\n")
+	shouldContain(t, out, "    code(db.not_found) (shadowed)\n")
+}
+
+func TestCodeStatus_String(t *testing.T) {
+	shouldEqual(t, failure.CodeEffective.String(), "effective")
+	shouldEqual(t, failure.CodeShadowed.String(), "shadowed")
+	shouldEqual(t, failure.CodeHidden.String(), "hidden")
+	shouldEqual(t, failure.CodeStatus(0).String(), "CodeStatus(0)")
+}
+
+func TestCodeHistoryOf_SameBranchesAsLayers(t *testing.T) {
+	err := failure.Translate(errors.Join(
+		failure.New(historyDB),
+		errors.Join(errors.New("xxx"), failure.Translate(failure.New(historyDB), historyUser)),
+	), historyAPI)
+
+	var branches []string
+	for _, r := range failure.CodeHistoryOf(err) {
+		branches = append(branches, r.Branch)
+	}
+	shouldEqual(t, branches, []string{"1", "2.2", "2.2", ""})
+
+	branches = nil
+	for _, l := range failure.Layers(err) {
+		branches = append(branches, l.Branch)
+	}
+	shouldEqual(t, branches, []string{"", "1", "2.2", "2.2"})
+
+	out := fmt.Sprintf("%+v", err)
+	for _, b := range []string{"1", "2", "2.1", "2.2"} {
+		shouldContain(t, out, fmt.Sprintf("[Branch %s]\n", b))
+	}
+}
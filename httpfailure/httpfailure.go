@@ -0,0 +1,201 @@
+// Package httpfailure provides HTTP error responses for errors created
+// by the failure package.
+package httpfailure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/morikuni/failure"
+)
+
+// StatusMap maps error codes to HTTP status codes.
+type StatusMap map[failure.Code]int
+
+// Status returns an HTTP status code for the err.
+// It returns 500 if the err has no code (e.g. an unexpected error).
+// The status is looked up by the map, and then by the HTTP status code
+// of the code if it is failure.HTTPStatusCode. If neither has the code,
+// its ancestors are looked up in the same way, and otherwise the fallback
+// is returned.
+func (m StatusMap) Status(err error, fallback int) int {
+	c, ok := failure.CodeOf(err)
+	if !ok {
+		return http.StatusInternalServerError
+	}
+	for _, c := range append([]failure.Code{c}, failure.AncestorsOf(c)...) {
+		if s, ok := m.lookup(c); ok {
+			return s
+		}
+		if s, ok := failure.CodeHTTPStatus(c); ok {
+			return s
+		}
+	}
+	return fallback
+}
+
+func (m StatusMap) lookup(code failure.Code) (int, bool) {
+	switch code.(type) {
+	case failure.EqualCoder, failure.PayloadCode:
+		return m.scan(code)
+	}
+	if !reflect.ValueOf(code).Comparable() {
+		// The code cannot be a map key, so compare it one by one.
+		return m.scan(code)
+	}
+	s, ok := m[code]
+	return s, ok
+}
+
+func (m StatusMap) scan(code failure.Code) (int, bool) {
+	for c, s := range m {
+		if failure.EqualCode(c, code) {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+// Response is an error response to be written.
+type Response struct {
+	// Status is an HTTP status code.
+	Status int
+	// Code is the error code. It is nil for unexpected errors.
+	Code failure.Code
+	// Message is a message for end users.
+	// It is the message from failure.PublicMessageOf if exists.
+	// Otherwise, it is the status text to hide the detail.
+	Message string
+	// Err is the original error.
+	Err error
+}
+
+// BodyWriter writes an error response including the header.
+type BodyWriter func(w http.ResponseWriter, r *http.Request, res Response)
+
+// Logger is called for each error response.
+// The detail is the error formatted with %+v.
+type Logger func(r *http.Request, res Response, detail string)
+
+// HandlerFunc is an HTTP handler returning an error.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// Responder writes error responses.
+// The zero value is ready to use and responds with 500 for all errors.
+type Responder struct {
+	// StatusMap maps error codes to HTTP status codes.
+	StatusMap StatusMap
+	// DefaultStatus is used for codes not in the StatusMap.
+	// If it is 0, 500 is used.
+	DefaultStatus int
+	// WriteBody writes the response. If it is nil, WriteJSON is used.
+	WriteBody BodyWriter
+	// Logger is called for each error response if it is not nil.
+	Logger Logger
+	// ExposeInternalMessage enables the fallback to failure.MessageOf when
+	// the error has no public message. By default, the status text is used
+	// instead not to leak messages for developers to clients.
+	ExposeInternalMessage bool
+}
+
+// Response creates a Response for the err.
+func (rp *Responder) Response(err error) Response {
+	fallback := rp.DefaultStatus
+	if fallback == 0 {
+		fallback = http.StatusInternalServerError
+	}
+
+	res := Response{
+		Status: rp.StatusMap.Status(err, fallback),
+		Err:    err,
+	}
+
+	c, ok := failure.CodeOf(err)
+	if !ok {
+		res.Message = http.StatusText(res.Status)
+		return res
+	}
+	res.Code = c
+	if msg, ok := failure.PublicMessageOf(err); ok {
+		res.Message = msg
+	} else if msg, ok := failure.MessageOf(err); ok && rp.ExposeInternalMessage {
+		res.Message = msg
+	} else {
+		res.Message = http.StatusText(res.Status)
+	}
+	return res
+}
+
+// Respond writes an error response for the err.
+func (rp *Responder) Respond(w http.ResponseWriter, r *http.Request, err error) {
+	res := rp.Response(err)
+
+	if rp.Logger != nil {
+		rp.Logger(r, res, fmt.Sprintf("%+v", err))
+	}
+
+	write := rp.WriteBody
+	if write == nil {
+		write = WriteJSON
+	}
+	write(w, r, res)
+}
+
+// Handler adapts f to http.Handler. If f returns an error, the error
+// response is written by Respond.
+// A panic in f is also written as an error by failure.Recover,
+// except for http.ErrAbortHandler.
+func (rp *Responder) Handler(f HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := serve(f, w, r); err != nil {
+			if errors.Is(err, http.ErrAbortHandler) {
+				panic(http.ErrAbortHandler)
+			}
+			rp.Respond(w, r, err)
+		}
+	})
+}
+
+// Recover wraps h to write a panic in h as an error response.
+func (rp *Responder) Recover(h http.Handler) http.Handler {
+	return rp.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		h.ServeHTTP(w, r)
+		return nil
+	})
+}
+
+func serve(f HandlerFunc, w http.ResponseWriter, r *http.Request) (err error) {
+	defer failure.Recover(&err)
+	return f(w, r)
+}
+
+// WriteJSON writes the response as JSON.
+//
+//	{"code": "NotFound", "message": "user not found"}
+//
+// "code" is omitted for unexpected errors and invalid codes (e.g. nil
+// pointers).
+func WriteJSON(w http.ResponseWriter, r *http.Request, res Response) {
+	body := struct {
+		Code    string `json:"code,omitempty"`
+		Message string `json:"message"`
+	}{
+		Message: res.Message,
+	}
+	if s, err := failure.ErrorCodeOf(res.Code); err == nil {
+		body.Code = s
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(res.Status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// WriteText writes the message of the response as plain text.
+func WriteText(w http.ResponseWriter, r *http.Request, res Response) {
+	http.Error(w, res.Message, res.Status)
+}
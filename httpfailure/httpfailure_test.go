@@ -0,0 +1,209 @@
+package httpfailure_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/morikuni/failure"
+	"github.com/morikuni/failure/codes"
+	"github.com/morikuni/failure/httpfailure"
+)
+
+const (
+	NotFound  failure.StringCode = "NotFound"
+	Forbidden failure.StringCode = "Forbidden"
+	Conflict  failure.StringCode = "Conflict"
+)
+
+func TestResponder(t *testing.T) {
+	var logs []string
+	rp := &httpfailure.Responder{
+		StatusMap: httpfailure.StatusMap{
+			NotFound:  http.StatusNotFound,
+			Forbidden: http.StatusForbidden,
+		},
+		DefaultStatus: http.StatusBadRequest,
+		Logger: func(r *http.Request, res httpfailure.Response, detail string) {
+			logs = append(logs, detail)
+		},
+	}
+
+	tests := map[string]struct {
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		"not found": {
+			err:        failure.New(NotFound, failure.Message("user not found")),
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"code":"NotFound","message":"Not Found"}` + "\n",
+		},
+		"public message": {
+			err:        failure.New(NotFound, failure.PublicMessage("The user is not found."), failure.Message("user not found")),
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"code":"NotFound","message":"The user is not found."}` + "\n",
+		},
+		"no message": {
+			err:        failure.Translate(io.EOF, Forbidden),
+			wantStatus: http.StatusForbidden,
+			wantBody:   `{"code":"Forbidden","message":"Forbidden"}` + "\n",
+		},
+		"default status": {
+			err:        failure.New(Conflict),
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"code":"Conflict","message":"Bad Request"}` + "\n",
+		},
+		"unexpected": {
+			err:        failure.Unexpected("database is down", failure.Message("secret")),
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"message":"Internal Server Error"}` + "\n",
+		},
+		"mark unexpected": {
+			err:        failure.MarkUnexpected(failure.New(NotFound, failure.Message("secret"))),
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"message":"Internal Server Error"}` + "\n",
+		},
+		"unknown error": {
+			err:        io.EOF,
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"message":"Internal Server Error"}` + "\n",
+		},
+	}
+
+	for title, test := range tests {
+		t.Run(title, func(t *testing.T) {
+			logs = nil
+			h := rp.Handler(func(w http.ResponseWriter, r *http.Request) error {
+				return test.err
+			})
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			shouldEqual(t, rec.Code, test.wantStatus)
+			shouldEqual(t, rec.Header().Get("Content-Type"), "application/json; charset=utf-8")
+			shouldEqual(t, rec.Body.String(), test.wantBody)
+			shouldEqual(t, len(logs), 1)
+			shouldEqual(t, logs[0], fmt.Sprintf("%+v", test.err))
+		})
+	}
+}
+
+func TestResponder_Zero(t *testing.T) {
+	var rp httpfailure.Responder
+	rp.WriteBody = httpfailure.WriteText
+
+	h := rp.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		return failure.New(NotFound, failure.Message("user not found"))
+	})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	shouldEqual(t, rec.Code, http.StatusInternalServerError)
+	shouldEqual(t, rec.Body.String(), "Internal Server Error\n")
+
+	h = rp.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	shouldEqual(t, rec.Code, http.StatusNoContent)
+}
+
+func TestResponder_Recover(t *testing.T) {
+	var detail string
+	rp := &httpfailure.Responder{
+		Logger: func(r *http.Request, res httpfailure.Response, d string) {
+			detail = d
+		},
+	}
+
+	h := rp.Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("oops")
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	shouldEqual(t, rec.Code, http.StatusInternalServerError)
+	shouldEqual(t, rec.Body.String(), `{"message":"Internal Server Error"}`+"\n")
+	shouldEqual(t, strings.HasPrefix(detail, "[httpfailure_test.TestResponder_Recover.func2]"), true)
+
+	h = rp.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		panic(failure.New(NotFound))
+	})
+	rp.StatusMap = httpfailure.StatusMap{NotFound: http.StatusNotFound}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	shouldEqual(t, rec.Code, http.StatusNotFound)
+
+	h = rp.Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	defer func() {
+		shouldEqual(t, recover(), http.ErrAbortHandler)
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestResponder_ExposeInternalMessage(t *testing.T) {
+	rp := &httpfailure.Responder{ExposeInternalMessage: true}
+
+	res := rp.Response(failure.New(NotFound, failure.Message("user not found")))
+	shouldEqual(t, res.Message, "user not found")
+
+	res = rp.Response(failure.New(NotFound, failure.PublicMessage("The user is not found.")))
+	shouldEqual(t, res.Message, "The user is not found.")
+}
+
+func TestStatusMap_HTTPStatus(t *testing.T) {
+	m := httpfailure.StatusMap{codes.NotFound: http.StatusGone}
+
+	shouldEqual(t, m.Status(failure.New(codes.NotFound), 0), http.StatusGone)
+	shouldEqual(t, m.Status(failure.New(codes.Unauthenticated), 0), http.StatusUnauthorized)
+	shouldEqual(t, m.Status(failure.New(NotFound), http.StatusBadRequest), http.StatusBadRequest)
+}
+
+func TestStatusMap_Hierarchy(t *testing.T) {
+	auth := failure.DefineCode("auth")
+	expired := failure.DefineCode("auth.expired").WithParent(auth)
+	locked := failure.DefineCode("auth.locked").WithParent(auth).WithHTTPStatus(http.StatusLocked)
+	m := httpfailure.StatusMap{auth: http.StatusUnauthorized}
+
+	shouldEqual(t, m.Status(failure.New(expired), 0), http.StatusUnauthorized)
+	shouldEqual(t, m.Status(failure.New(locked), 0), http.StatusLocked)
+	shouldEqual(t, m.Status(failure.New(failure.DefineCode("x").WithParent(codes.NotFound)), 0), http.StatusNotFound)
+}
+
+type sliceCode []string
+
+func (c sliceCode) ErrorCode() string {
+	return strings.Join(c, ".")
+}
+
+func TestStatusMap_NonComparableCode(t *testing.T) {
+	m := httpfailure.StatusMap{NotFound: http.StatusNotFound}
+
+	shouldEqual(t, m.Status(failure.New(sliceCode{"a"}), http.StatusBadRequest), http.StatusBadRequest)
+	shouldEqual(t, m.Status(failure.New(NotFound), 0), http.StatusNotFound)
+}
+
+type pointerCode struct {
+	name string
+}
+
+func (c *pointerCode) ErrorCode() string {
+	return c.name
+}
+
+func TestResponder_NilPointerCode(t *testing.T) {
+	for _, write := range []httpfailure.BodyWriter{httpfailure.WriteJSON, httpfailure.ProblemWriter{}.Write} {
+		rp := &httpfailure.Responder{WriteBody: write}
+		rec := httptest.NewRecorder()
+		rp.Respond(rec, httptest.NewRequest(http.MethodGet, "/", nil), failure.New((*pointerCode)(nil)))
+		shouldEqual(t, rec.Code, http.StatusInternalServerError)
+		shouldEqual(t, strings.Contains(rec.Body.String(), `"code"`), false)
+	}
+}
@@ -0,0 +1,263 @@
+package httpfailure
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/morikuni/failure"
+)
+
+// ProblemContentType is the media type of problem details.
+const ProblemContentType = "application/problem+json"
+
+// Problem is a problem details object defined in RFC 9457.
+type Problem struct {
+	Type     string
+	Title    string
+	Status   int
+	Detail   string
+	Instance string
+	// Extensions are extension members of the problem.
+	// The error code is stored with the key "code".
+	Extensions map[string]interface{}
+}
+
+var problemMembers = map[string]bool{
+	"type":     true,
+	"title":    true,
+	"status":   true,
+	"detail":   true,
+	"instance": true,
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		if !problemMembers[k] {
+			m[k] = v
+		}
+	}
+	if p.Type != "" {
+		m["type"] = p.Type
+	}
+	if p.Title != "" {
+		m["title"] = p.Title
+	}
+	if p.Status != 0 {
+		m["status"] = p.Status
+	}
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+
+	*p = Problem{}
+	fields := map[string]interface{}{
+		"type":     &p.Type,
+		"title":    &p.Title,
+		"status":   &p.Status,
+		"detail":   &p.Detail,
+		"instance": &p.Instance,
+	}
+	for k, raw := range m {
+		if f, ok := fields[k]; ok {
+			// RFC 9457 requires to ignore members of a wrong type.
+			json.Unmarshal(raw, f)
+			continue
+		}
+
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if p.Extensions == nil {
+			p.Extensions = make(map[string]interface{})
+		}
+		p.Extensions[k] = v
+	}
+	return nil
+}
+
+// ParseProblem parses a problem details document.
+// The type defaults to "about:blank" if it is absent.
+func ParseProblem(r io.Reader) (*Problem, error) {
+	var p Problem
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, err
+	}
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	return &p, nil
+}
+
+// Error implements the error interface.
+// The problem is the cause of the error returned by Err.
+func (p *Problem) Error() string {
+	switch {
+	case p.Status != 0 && p.Title != "":
+		return fmt.Sprintf("%d %s", p.Status, p.Title)
+	case p.Status != 0:
+		return strconv.Itoa(p.Status)
+	case p.Title != "":
+		return p.Title
+	}
+	return "problem(" + p.Type + ")"
+}
+
+// Err converts the problem to an error created by the failure package.
+// The cause of the error is the problem itself.
+// The code is taken from the extension member "code" and resolved by the
+// resolver. If the resolver is nil, failure.DefaultCodeRegistry is used,
+// and unknown codes are converted to failure.StringCode.
+// The other extension members are attached as failure.Context.
+// If the problem has no code, an unexpected error is returned.
+// The error has no call stack since it is not created by the caller,
+// so wrap it with failure.Wrap where it is received.
+func (p *Problem) Err(resolver failure.CodeResolver) error {
+	wrappers := []failure.Wrapper{failure.WithFormatter()}
+	if msg := p.Detail; msg != "" {
+		wrappers = append(wrappers, failure.Message(msg))
+	} else if msg := p.Title; msg != "" {
+		wrappers = append(wrappers, failure.Message(msg))
+	}
+
+	ctx := failure.Context{}
+	for k, v := range p.Extensions {
+		if k == "code" {
+			continue
+		}
+		if s, ok := v.(string); ok {
+			ctx[k] = s
+		} else {
+			b, _ := json.Marshal(v)
+			ctx[k] = string(b)
+		}
+	}
+	if len(ctx) != 0 {
+		wrappers = append(wrappers, ctx)
+	}
+
+	s, ok := p.Extensions["code"].(string)
+	if !ok {
+		return failure.Custom(p, append(wrappers, failure.WithUnexpected())...)
+	}
+
+	if resolver == nil {
+		resolver = failure.DefaultCodeRegistry
+	}
+	code, ok := resolver.ResolveCode(s)
+	if !ok {
+		code = failure.StringCode(s)
+	}
+	return failure.Custom(p, append(wrappers, failure.WithCode(code))...)
+}
+
+// ContextExtensions returns a function for ProblemWriter.Extensions which
+// writes values of the keys in failure.Context and failure.TypedContext
+// attached to the error. The outer context takes precedence. Values of
+// failure.TypedContext are written as JSON values of their types.
+func ContextExtensions(keys ...string) func(err error) map[string]interface{} {
+	allowed := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		allowed[k] = true
+	}
+
+	return func(err error) map[string]interface{} {
+		ext := map[string]interface{}{}
+		i := failure.NewIterator(err)
+		for i.Next() {
+			var (
+				tc  failure.TypedContext
+				ctx failure.Context
+			)
+			switch {
+			case i.As(&tc):
+				for k, v := range tc {
+					if _, ok := ext[k]; !ok && allowed[k] {
+						ext[k] = v
+					}
+				}
+			case i.As(&ctx):
+				for k, v := range ctx {
+					if _, ok := ext[k]; !ok && allowed[k] {
+						ext[k] = v
+					}
+				}
+			}
+		}
+		return ext
+	}
+}
+
+// ProblemWriter writes error responses as problem details.
+// The error code is written as the extension member "code" unless it is
+// invalid (e.g. a nil pointer).
+// Context attached to the error is for debugging and is not written
+// unless Extensions returns it (see ContextExtensions).
+type ProblemWriter struct {
+	// TypeURI returns a URI which identifies the problem type of the code.
+	// If it is nil, "about:blank" is used.
+	TypeURI func(code failure.Code) string
+	// Extensions returns extension members of the problem for the error.
+	// It is not called for unexpected errors.
+	// Members defined by RFC 9457 and "code" cannot be overwritten.
+	Extensions func(err error) map[string]interface{}
+}
+
+// Problem creates a problem details object from the response.
+func (pw ProblemWriter) Problem(res Response) *Problem {
+	p := &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(res.Status),
+		Status: res.Status,
+		Detail: res.Message,
+	}
+	if res.Code == nil {
+		return p
+	}
+
+	if pw.TypeURI != nil {
+		p.Type = pw.TypeURI(res.Code)
+	}
+	p.Extensions = map[string]interface{}{}
+	if pw.Extensions != nil {
+		for k, v := range pw.Extensions(res.Err) {
+			p.Extensions[k] = v
+		}
+	}
+	if s, err := failure.ErrorCodeOf(res.Code); err == nil {
+		p.Extensions["code"] = s
+	}
+	return p
+}
+
+// Write writes the response as problem details.
+// It can be used as BodyWriter.
+func (pw ProblemWriter) Write(w http.ResponseWriter, r *http.Request, res Response) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(res.Status)
+	json.NewEncoder(w).Encode(pw.Problem(res))
+}
+
+// WriteProblem writes the response as problem details with the
+// default ProblemWriter.
+func WriteProblem(w http.ResponseWriter, r *http.Request, res Response) {
+	ProblemWriter{}.Write(w, r, res)
+}
@@ -0,0 +1,140 @@
+package httpfailure_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/morikuni/failure"
+	"github.com/morikuni/failure/httpfailure"
+)
+
+func TestProblemWriter(t *testing.T) {
+	rp := &httpfailure.Responder{
+		StatusMap: httpfailure.StatusMap{
+			NotFound: http.StatusNotFound,
+		},
+		WriteBody: httpfailure.ProblemWriter{
+			TypeURI: func(c failure.Code) string {
+				return "https://example.com/problems/" + c.ErrorCode()
+			},
+			Extensions: httpfailure.ContextExtensions("user_id", "admin", "status"),
+		}.Write,
+	}
+
+	tests := map[string]struct {
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		"not found": {
+			err: failure.Translate(
+				failure.New(NotFound, failure.Context{"user_id": "1", "status": "x"}),
+				NotFound,
+				failure.PublicMessage("user not found"),
+				failure.Context{"user_id": "2"},
+			),
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"code":"NotFound","detail":"user not found","status":404,"title":"Not Found","type":"https://example.com/problems/NotFound","user_id":"2"}` + "\n",
+		},
+		"typed context": {
+			err:        failure.New(NotFound, failure.TypedContext{"user_id": 2, "admin": true}, failure.Context{"user_id": "1"}),
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"admin":true,"code":"NotFound","detail":"Not Found","status":404,"title":"Not Found","type":"https://example.com/problems/NotFound","user_id":2}` + "\n",
+		},
+		"not allowed context": {
+			err:        failure.New(NotFound, failure.Context{"query": "SELECT"}),
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"code":"NotFound","detail":"Not Found","status":404,"title":"Not Found","type":"https://example.com/problems/NotFound"}` + "\n",
+		},
+		"unexpected": {
+			err:        failure.Unexpected("database is down", failure.Context{"query": "SELECT", "user_id": "1"}),
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"detail":"Internal Server Error","status":500,"title":"Internal Server Error","type":"about:blank"}` + "\n",
+		},
+	}
+
+	for title, test := range tests {
+		t.Run(title, func(t *testing.T) {
+			h := rp.Handler(func(w http.ResponseWriter, r *http.Request) error {
+				return test.err
+			})
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			shouldEqual(t, rec.Code, test.wantStatus)
+			shouldEqual(t, rec.Header().Get("Content-Type"), httpfailure.ProblemContentType)
+			shouldEqual(t, rec.Body.String(), test.wantBody)
+		})
+	}
+}
+
+func TestParseProblem(t *testing.T) {
+	resolver := failure.CodeResolverFunc(func(s string) (failure.Code, bool) {
+		if s == string(NotFound) {
+			return NotFound, true
+		}
+		return nil, false
+	})
+
+	p, err := httpfailure.ParseProblem(strings.NewReader(
+		`{"type":"https://example.com/problems/NotFound","title":"Not Found","status":404,"detail":"user not found","code":"NotFound","user_id":"1","count":2}`,
+	))
+	shouldEqual(t, err, nil)
+	shouldEqual(t, p, &httpfailure.Problem{
+		Type:   "https://example.com/problems/NotFound",
+		Title:  "Not Found",
+		Status: 404,
+		Detail: "user not found",
+		Extensions: map[string]interface{}{
+			"code":    "NotFound",
+			"user_id": "1",
+			"count":   float64(2),
+		},
+	})
+
+	e := p.Err(resolver)
+	shouldEqual(t, failure.Is(e, NotFound), true)
+	shouldEqual(t, failure.CauseOf(e), error(p))
+	_, ok := failure.CallStackOf(e)
+	shouldEqual(t, ok, false)
+	msg, _ := failure.MessageOf(e)
+	shouldEqual(t, msg, "user not found")
+	var ctx failure.Context
+	i := failure.NewIterator(e)
+	for i.Next() {
+		if i.As(&ctx) {
+			break
+		}
+	}
+	shouldEqual(t, ctx, failure.Context{"user_id": "1", "count": "2"})
+
+	e = p.Err(nil)
+	c, _ := failure.CodeOf(e)
+	shouldEqual(t, c, failure.StringCode("NotFound"))
+
+	p, err = httpfailure.ParseProblem(strings.NewReader(`{"status":"500","detail":"Internal Server Error"}`))
+	shouldEqual(t, err, nil)
+	shouldEqual(t, p.Type, "about:blank")
+	shouldEqual(t, p.Status, 0)
+	e = p.Err(resolver)
+	_, ok = failure.CodeOf(e)
+	shouldEqual(t, ok, false)
+	shouldEqual(t, e.Error(), "Internal Server Error: unexpected: problem(about:blank)")
+
+	b, err := json.Marshal(httpfailure.Problem{Type: "about:blank", Status: 400, Extensions: map[string]interface{}{"type": "x", "a": 1}})
+	shouldEqual(t, err, nil)
+	shouldEqual(t, string(b), `{"a":1,"status":400,"type":"about:blank"}`)
+}
+
+func TestProblemWriter_NoExtensions(t *testing.T) {
+	p := httpfailure.ProblemWriter{}.Problem(httpfailure.Response{
+		Status: http.StatusNotFound,
+		Code:   NotFound,
+		Err:    failure.New(NotFound, failure.Context{"user_id": "1"}, failure.TypedContext{"admin": true}),
+	})
+	shouldEqual(t, p.Extensions, map[string]interface{}{"code": "NotFound"})
+}
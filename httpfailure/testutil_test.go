@@ -0,0 +1,13 @@
+package httpfailure_test
+
+import (
+	"reflect"
+	"testing"
+)
+
+func shouldEqual(t *testing.T, a, b interface{}) {
+	t.Helper()
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("%T(%#v) does not equal to %T(%#v)", a, a, b, b)
+	}
+}
@@ -1,47 +1,202 @@
 package failure
 
+import "fmt"
+
 // NewIterator creates an iterator for the err.
+//
+// When the err contains multiple errors (i.e. it implements
+// `Unwrap() []error` like errors created by errors.Join), the iterator
+// walks them in depth-first order, visiting branches from left to right.
 func NewIterator(err error) *Iterator {
-	return &Iterator{guardianUnwapper{err}}
+	return &Iterator{err: unhideCode(err)}
 }
 
 // Iterator is designed to iterate wrapped errors with for loop.
 type Iterator struct {
-	err error
+	err     error
+	stack   []error
+	skip    bool
+	started bool
 }
 
 // Next tries to unwrap an error and returns whether the next
 // error is present. Since this method updates internal state of the
 // iterator, should be called only once per iteration.
 func (i *Iterator) Next() bool {
-	i.err = i.unwrapError()
-	if i.err == nil {
+	if !i.started {
+		i.started = true
+		return i.err != nil
+	}
+
+	if !i.skip {
+		next, errs := unwrap(i.err)
+		if errs != nil {
+			// Push in reverse order so that the first branch is popped first.
+			for j := len(errs) - 1; j >= 0; j-- {
+				if e := unhideCode(errs[j]); e != nil {
+					i.stack = append(i.stack, e)
+				}
+			}
+		} else if next != nil {
+			i.err = next
+			return true
+		}
+	}
+	i.skip = false
+
+	if len(i.stack) == 0 {
+		i.err = nil
 		return false
 	}
+	i.err = i.stack[len(i.stack)-1]
+	i.stack = i.stack[:len(i.stack)-1]
 	return true
 }
 
-func (i *Iterator) unwrapError() error {
+// skipUnderlying makes the next call of Next skip errors wrapped by
+// the current error. Other branches are still visited.
+func (i *Iterator) skipUnderlying() {
+	i.skip = true
+}
+
+// unwrap returns the underlying error of the err.
+// If the err has multiple underlying errors, they are returned as errs
+// without modification.
+func unwrap(err error) (next error, errs []error) {
 	type causer interface {
 		Cause() error
 	}
 	type go113error interface {
 		Unwrap() error
 	}
+	type go120error interface {
+		Unwrap() []error
+	}
 	// For backward compatibility with v0 failure.
 	type oldFailureError interface {
 		UnwrapError() error
 	}
 
-	switch t := i.err.(type) {
+	switch t := err.(type) {
 	case go113error:
-		return t.Unwrap()
+		return unhideCode(t.Unwrap()), nil
+	case go120error:
+		return nil, t.Unwrap()
 	case oldFailureError:
-		return t.UnwrapError()
+		return unhideCode(t.UnwrapError()), nil
 	case causer:
-		return t.Cause()
+		return unhideCode(t.Cause()), nil
+	}
+	return nil, nil
+}
+
+// unwrapErrors returns the underlying errors of the err.
+func unwrapErrors(err error) []error {
+	next, errs := unwrap(err)
+	if errs == nil {
+		if next == nil {
+			return nil
+		}
+		return []error{next}
+	}
+
+	unhidden := make([]error, len(errs))
+	for i, e := range errs {
+		unhidden[i] = unhideCode(e)
+	}
+	return unhidden
+}
+
+func unhideCode(err error) error {
+	switch t := err.(type) {
+	case *codeHider:
+		return t.error
+	case *multiCodeHider:
+		return t.error
+	}
+	return err
+}
+
+// hasUnderlying reports whether the err wraps any error.
+func hasUnderlying(err error) bool {
+	next, errs := unwrap(err)
+	if next != nil {
+		return true
+	}
+	for _, e := range errs {
+		if e != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// chainNode is an error in the chain visited by walkChain.
+type chainNode struct {
+	err error
+	// branch is the path of the branch which the err belongs to.
+	branch string
+	// errs are the non-nil errors wrapped by the err.
+	errs []error
+	// scope is the scope of codes in the err.
+	scope codeScope
+}
+
+// chainVisitor is a set of callbacks of walkChain.
+type chainVisitor struct {
+	// enter is called at the beginning of each branch with the path of the
+	// branch (e.g. "1.2"). The path of the top of the chain is empty.
+	enter func(branch string)
+	// visit is called for each error in the branch from outer to inner.
+	visit func(n chainNode)
+	// leave is called at the end of each branch after its sub branches.
+	leave func(branch string)
+}
+
+// walkChain walks the chain of the err from outer to inner.
+// Each branch of multiple errors is walked in depth-first order from left
+// to right after the error having them.
+// It is the traversal shared by %+v, Layers and CodeHistoryOf.
+func walkChain(err error, v chainVisitor) {
+	walkBranch(err, "", codeScope{}, v)
+}
+
+func walkBranch(err error, branch string, scope codeScope, v chainVisitor) {
+	if v.enter != nil {
+		v.enter(branch)
+	}
+	if v.leave != nil {
+		defer v.leave(branch)
+	}
+
+	for err != nil {
+		var errs []error
+		for _, e := range unwrapErrors(err) {
+			if e != nil {
+				errs = append(errs, e)
+			}
+		}
+
+		v.visit(chainNode{err, branch, errs, scope})
+		var code Code
+		scope = scope.enter(err, (&Iterator{err: err}).As(&code))
+
+		if len(errs) > 1 {
+			prefix := ""
+			if branch != "" {
+				prefix = branch + "."
+			}
+			for j, e := range errs {
+				walkBranch(e, fmt.Sprintf("%s%d", prefix, j+1), scope, v)
+			}
+			return
+		}
+
+		err = nil
+		if len(errs) == 1 {
+			err = errs[0]
+		}
 	}
-	return nil
 }
 
 // Error returns current error.
@@ -60,25 +215,20 @@ func (i *Iterator) As(x interface{}) bool {
 	}
 }
 
-type guardianUnwapper struct {
-	error
-}
-
-func (w guardianUnwapper) Unwrap() error {
-	return w.error
-}
-
 // CauseOf returns a most underlying error of the err.
+// If the err contains multiple errors, the cause of the first
+// branch is returned.
 func CauseOf(err error) error {
 	if err == nil {
 		return nil
 	}
 
-	var last error
 	i := NewIterator(err)
 	for i.Next() {
-		last = i.Error()
+		if !hasUnderlying(i.Error()) {
+			return i.Error()
+		}
 	}
 
-	return last
+	return nil
 }
@@ -1,6 +1,7 @@
 package failure_test
 
 import (
+	"errors"
 	"io"
 	"reflect"
 	"testing"
@@ -56,3 +57,29 @@ func TestCauseOf(t *testing.T) {
 
 	shouldEqual(t, failure.CauseOf(nil), nil)
 }
+
+func TestIterator_MultiError(t *testing.T) {
+	err := a{errors.Join(b{io.EOF}, nil, errors.Join(c{io.ErrUnexpectedEOF}, io.ErrClosedPipe))}
+	want := []error{
+		err,
+		err.error,
+		b{io.EOF},
+		io.EOF,
+		errors.Join(c{io.ErrUnexpectedEOF}, io.ErrClosedPipe),
+		c{io.ErrUnexpectedEOF},
+		io.ErrUnexpectedEOF,
+		io.ErrClosedPipe,
+	}
+
+	var got []error
+	i := failure.NewIterator(err)
+	for i.Next() {
+		got = append(got, i.Error())
+	}
+	shouldEqual(t, got, want)
+}
+
+func TestCauseOf_MultiError(t *testing.T) {
+	err := failure.Wrap(errors.Join(nil, failure.Wrap(io.EOF), io.ErrUnexpectedEOF))
+	shouldEqual(t, failure.CauseOf(err), io.EOF)
+}
@@ -0,0 +1,437 @@
+package failure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var _ = []json.Marshaler{
+	(*withMessage)(nil),
+	(*withPublicMessage)(nil),
+	(*withMessageID)(nil),
+	(*withContext)(nil),
+	(*withTypedContext)(nil),
+	(*withCallStack)(nil),
+	(*formatter)(nil),
+	(*withCode)(nil),
+	(*withUnexpected)(nil),
+}
+
+// CodeResolver resolves a Code from its string representation.
+// It is used by Unmarshal to reconstruct codes.
+type CodeResolver interface {
+	// ResolveCode returns a Code whose ErrorCode() is s.
+	ResolveCode(s string) (Code, bool)
+}
+
+// CodeResolverFunc is an adaptor to use function as the CodeResolver interface.
+type CodeResolverFunc func(s string) (Code, bool)
+
+// ResolveCode implements the CodeResolver interface.
+func (f CodeResolverFunc) ResolveCode(s string) (Code, bool) {
+	return f(s)
+}
+
+// jsonError is the JSON representation of an error chain.
+//
+//	{
+//	  "error": "main.Foo: xxx: code(not_found)",
+//	  "cause": "code(not_found)",
+//	  "chain": [
+//	    {"call_stack": [{"func": "main.Foo", "file": "/src/main.go", "line": 10}], "truncated": true},
+//	    {"message": "xxx"},
+//	    {"public_message": "xxx"},
+//	    {"message_id": "xxx"},
+//	    {"context": {"key": "value"}},
+//	    {"ordered_context": [{"key": "key", "value": "value"}]},
+//	    {"typed_context": {"key": 1}},
+//	    {"code": "not_found"},
+//	    {"code": "rate_limited", "payload": {"RetryAfter": 1000000000}},
+//	    {"code": null},
+//	    {"unexpected": true},
+//	    {"unexpected": true, "error": "unexpected error"},
+//	    {"type": "*errors.errorString", "error": "EOF"},
+//	    {"type": "*errors.joinError", "error": "...", "branches": [{...}, {...}]}
+//	  ]
+//	}
+//
+// Each element of the chain corresponds to an error from Iterator.
+// Values of "typed_context" which cannot be encoded to JSON are encoded
+// as strings. "payload" is JSON of the code if it is PayloadCode.
+// "code": null is a nil code (e.g. failure.New(nil)).
+// "unexpected" without "error" is a mark of MarkUnexpected, and with
+// "error" is an error created by Unexpected.
+// Entries are written even if they are empty (e.g. {"context": {}}), and
+// Unmarshal rejects entries whose kind is unknown.
+type jsonError struct {
+	Error string      `json:"error"`
+	Cause string      `json:"cause"`
+	Chain []jsonEntry `json:"chain"`
+}
+
+// jsonEntry is an element of the chain. Fields which distinguish the kind
+// of the entry are pointers so that they are written even if they are
+// empty (e.g. an empty Context).
+type jsonEntry struct {
+	CallStack  *[]jsonFrame    `json:"call_stack,omitempty"`
+	Truncated  bool            `json:"truncated,omitempty"`
+	Message    *string         `json:"message,omitempty"`
+	Public     *string         `json:"public_message,omitempty"`
+	MessageID  *string         `json:"message_id,omitempty"`
+	Context    *Context        `json:"context,omitempty"`
+	Ordered    *OrderedContext `json:"ordered_context,omitempty"`
+	Typed      *TypedContext   `json:"typed_context,omitempty"`
+	Code       json.RawMessage `json:"code,omitempty"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	Unexpected bool            `json:"unexpected,omitempty"`
+	Type       string          `json:"type,omitempty"`
+	Error      *string         `json:"error,omitempty"`
+	Branches   []jsonError     `json:"branches,omitempty"`
+}
+
+type jsonFrame struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// Marshal returns the JSON encoding of the err.
+// The returned JSON keeps codes, messages, contexts, call stacks and
+// unexpected marks so that the error can be reconstructed by Unmarshal.
+// Errors created by this package also implement json.Marshaler with this
+// function.
+// It returns an error if the err has an invalid code (e.g. a nil pointer).
+func Marshal(err error) ([]byte, error) {
+	if err == nil {
+		return []byte("null"), nil
+	}
+	je, encodeErr := encodeError(err)
+	if encodeErr != nil {
+		return nil, encodeErr
+	}
+	return json.Marshal(je)
+}
+
+func encodeError(err error) (jsonError, error) {
+	type formatter interface {
+		IsFormatter()
+	}
+
+	je := jsonError{
+		Error: err.Error(),
+		Cause: CauseOf(err).Error(),
+		Chain: []jsonEntry{},
+	}
+
+	i := NewIterator(err)
+	for i.Next() {
+		err := i.Error()
+		if _, ok := err.(formatter); ok {
+			continue
+		}
+
+		var (
+			cs   CallStack
+			tc   TypedContext
+			ctx  Contexter
+			msg  Messenger
+			pub  PublicMessage
+			id   MessageID
+			code Code
+		)
+		var ordered OrderedContext
+		if w, ok := err.(*withContext); ok {
+			ordered = w.render().ordered
+		}
+		switch {
+		case ordered != nil:
+			je.Chain = append(je.Chain, jsonEntry{Ordered: &ordered})
+		case i.As(&cs):
+			frames := []jsonFrame{}
+			for _, f := range cs.Frames() {
+				frames = append(frames, jsonFrame{funcNameOf(f), f.Path(), f.Line()})
+			}
+			je.Chain = append(je.Chain, jsonEntry{CallStack: &frames, Truncated: IsTruncated(cs)})
+		case i.As(&tc):
+			encoded := encodeTypedContext(tc)
+			je.Chain = append(je.Chain, jsonEntry{Typed: &encoded})
+		case i.As(&ctx):
+			c := Context{}
+			for k, v := range ctx.Context() {
+				c[k] = v
+			}
+			je.Chain = append(je.Chain, jsonEntry{Context: &c})
+		case i.As(&msg):
+			m := msg.Message()
+			je.Chain = append(je.Chain, jsonEntry{Message: &m})
+		case i.As(&pub):
+			m := pub.String()
+			je.Chain = append(je.Chain, jsonEntry{Public: &m})
+		case i.As(&id):
+			m := string(id)
+			je.Chain = append(je.Chain, jsonEntry{MessageID: &m})
+		case i.As(&code):
+			e, encodeErr := encodeCode(code)
+			if encodeErr != nil {
+				return jsonError{}, encodeErr
+			}
+			je.Chain = append(je.Chain, e)
+		default:
+			e, encodeErr := encodeOther(err)
+			if encodeErr != nil {
+				return jsonError{}, encodeErr
+			}
+			je.Chain = append(je.Chain, e)
+			if len(unwrapErrors(err)) > 1 {
+				// Branches are encoded in the entry.
+				return je, nil
+			}
+		}
+	}
+
+	return je, nil
+}
+
+func encodeCode(code Code) (jsonEntry, error) {
+	if code == nil {
+		return jsonEntry{Code: json.RawMessage("null")}, nil
+	}
+	s, err := ErrorCodeOf(code)
+	if err != nil {
+		return jsonEntry{}, err
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return jsonEntry{}, err
+	}
+	return jsonEntry{Code: b, Payload: encodePayload(code)}, nil
+}
+
+func encodeTypedContext(tc TypedContext) TypedContext {
+	encoded := make(TypedContext, len(tc))
+	for k, v := range tc {
+		if _, err := json.Marshal(v); err != nil {
+			encoded[k] = fmt.Sprint(v)
+			continue
+		}
+		encoded[k] = v
+	}
+	return encoded
+}
+
+func encodeOther(err error) (jsonEntry, error) {
+	switch t := err.(type) {
+	case *withUnexpected:
+		return jsonEntry{Unexpected: true}, nil
+	case unexpected:
+		msg := string(t)
+		return jsonEntry{Unexpected: true, Error: &msg}, nil
+	}
+
+	msg := err.Error()
+	e := jsonEntry{
+		Type:  fmt.Sprintf("%T", err),
+		Error: &msg,
+	}
+	switch t := err.(type) {
+	case *decodedError:
+		e.Type = t.typ
+	case *decodedMultiError:
+		e.Type = t.typ
+	}
+	e.Unexpected = isUnexpected(err)
+	if errs := unwrapErrors(err); len(errs) > 1 {
+		e.Branches = []jsonError{}
+		for _, err := range errs {
+			if err != nil {
+				b, encodeErr := encodeError(err)
+				if encodeErr != nil {
+					return jsonEntry{}, encodeErr
+				}
+				e.Branches = append(e.Branches, b)
+			}
+		}
+	}
+	return e, nil
+}
+
+// Unmarshal parses the JSON encoded by Marshal and stores the
+// reconstructed error in the target. The target is left unchanged if the
+// data cannot be decoded.
+//
+//	var err error
+//	if e := failure.Unmarshal(data, &err, nil); e != nil {
+//		...
+//	}
+//
+// Codes are reconstructed by the resolver. If the resolver is nil,
+// DefaultCodeRegistry is used. If the resolver does not know the code,
+// the code is reconstructed as StringCode. The payload of PayloadCode is
+// decoded into a copy of the resolved code.
+// Values of TypedContext are reconstructed as values decoded by
+// encoding/json (e.g. float64 for numbers).
+// Errors which are not created by this package are reconstructed as errors
+// which have the same error message.
+func Unmarshal(data []byte, target *error, resolver CodeResolver) error {
+	var je *jsonError
+	if err := json.Unmarshal(data, &je); err != nil {
+		return err
+	}
+	if je == nil {
+		*target = nil
+		return nil
+	}
+
+	decoded, err := decodeError(*je, resolver)
+	if err != nil {
+		return err
+	}
+	*target = &formatter{decoded}
+	return nil
+}
+
+func decodeError(je jsonError, resolver CodeResolver) (error, error) {
+	var err error
+	for i := len(je.Chain) - 1; i >= 0; i-- {
+		e := je.Chain[i]
+		switch {
+		case e.CallStack != nil:
+			frames := make([]Frame, len(*e.CallStack))
+			for j, f := range *e.CallStack {
+				frames[j] = frame{f.File, f.Line, f.Func, 0}
+			}
+			err = &withCallStack{frameCallStack{frames, e.Truncated}, err}
+		case e.Context != nil:
+			err = e.Context.WrapError(err)
+		case e.Ordered != nil:
+			err = e.Ordered.WrapError(err)
+		case e.Typed != nil:
+			err = e.Typed.WrapError(err)
+		case e.Message != nil:
+			err = Message(*e.Message).WrapError(err)
+		case e.Public != nil:
+			err = PublicMessage(*e.Public).WrapError(err)
+		case e.MessageID != nil:
+			err = MessageID(*e.MessageID).WrapError(err)
+		case e.Code != nil:
+			code, decodeErr := decodeCode(e.Code, e.Payload, resolver)
+			if decodeErr != nil {
+				return nil, decodeErr
+			}
+			err = &withCode{code, err}
+		case e.Type == "" && e.Unexpected && e.Error == nil:
+			err = &withUnexpected{err}
+		case e.Type == "" && e.Unexpected:
+			if err != nil {
+				return nil, errors.New("failure: unexpected error must be the last in the chain")
+			}
+			err = unexpected(*e.Error)
+		case e.Type == "" || e.Error == nil:
+			return nil, fmt.Errorf("failure: unknown entry at %d in the chain", i)
+		case e.Branches != nil:
+			if err != nil {
+				return nil, errors.New("failure: multiple errors must be the last in the chain")
+			}
+			errs := make([]error, len(e.Branches))
+			for j, b := range e.Branches {
+				be, decodeErr := decodeError(b, resolver)
+				if decodeErr != nil {
+					return nil, decodeErr
+				}
+				errs[j] = be
+			}
+			err = &decodedMultiError{decodedError{e.Type, *e.Error, e.Unexpected, nil}, errs}
+		default:
+			err = &decodedError{e.Type, *e.Error, e.Unexpected, err}
+		}
+	}
+	return err, nil
+}
+
+func decodeCode(data, payload json.RawMessage, resolver CodeResolver) (Code, error) {
+	if string(data) == "null" {
+		return nil, nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failure: failed to decode code: %v", err)
+	}
+	return decodePayload(resolveCode(s, resolver), payload)
+}
+
+func resolveCode(s string, resolver CodeResolver) Code {
+	if resolver == nil {
+		resolver = DefaultCodeRegistry
+	}
+	if c, ok := resolver.ResolveCode(s); ok {
+		return c
+	}
+	return StringCode(s)
+}
+
+// decodedError is an error reconstructed from an error which is not
+// created by this package.
+type decodedError struct {
+	typ        string
+	msg        string
+	unexpected bool
+	underlying error
+}
+
+func (e *decodedError) Error() string {
+	return e.msg
+}
+
+func (e *decodedError) Unwrap() error {
+	return e.underlying
+}
+
+func (e *decodedError) Unexpected() bool {
+	return e.unexpected
+}
+
+type decodedMultiError struct {
+	decodedError
+	errs []error
+}
+
+func (e *decodedMultiError) Unwrap() []error {
+	return e.errs
+}
+
+func (w *withMessage) MarshalJSON() ([]byte, error) {
+	return Marshal(w)
+}
+
+func (w *withPublicMessage) MarshalJSON() ([]byte, error) {
+	return Marshal(w)
+}
+
+func (w *withMessageID) MarshalJSON() ([]byte, error) {
+	return Marshal(w)
+}
+
+func (w *withContext) MarshalJSON() ([]byte, error) {
+	return Marshal(w)
+}
+
+func (w *withTypedContext) MarshalJSON() ([]byte, error) {
+	return Marshal(w)
+}
+
+func (w *withCallStack) MarshalJSON() ([]byte, error) {
+	return Marshal(w)
+}
+
+func (f *formatter) MarshalJSON() ([]byte, error) {
+	return Marshal(f)
+}
+
+func (w *withCode) MarshalJSON() ([]byte, error) {
+	return Marshal(w)
+}
+
+func (w *withUnexpected) MarshalJSON() ([]byte, error) {
+	return Marshal(w)
+}
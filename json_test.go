@@ -0,0 +1,195 @@
+package failure_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/morikuni/failure"
+)
+
+func TestMarshal(t *testing.T) {
+	err := failure.Translate(
+		failure.New(TestCodeA, failure.Message("xxx"), failure.Context{"zzz": "true"}),
+		TestCodeB,
+	)
+
+	b, e := json.Marshal(err)
+	shouldEqual(t, e, nil)
+	shouldMatch(t, string(b), `^{"error":"failure_test.TestMarshal: code\(1\): failure_test.TestMarshal: xxx: zzz=true: code\(code_a\)","cause":"code\(code_a\)","chain":\[`+
+		`{"call_stack":\[{"func":"github.com/morikuni/failure_test.TestMarshal","file":"/.+/failure/json_test.go","line":14},.*\]},`+
+		`{"code":"1"},`+
+		`{"call_stack":\[{"func":"github.com/morikuni/failure_test.TestMarshal","file":"/.+/failure/json_test.go","line":15},.*\]},`+
+		`{"message":"xxx"},`+
+		`{"context":{"zzz":"true"}},`+
+		`{"code":"code_a"}\]}$`,
+	)
+
+	b2, e := failure.Marshal(err)
+	shouldEqual(t, e, nil)
+	shouldEqual(t, b2, b)
+
+	b, e = failure.Marshal(nil)
+	shouldEqual(t, e, nil)
+	shouldEqual(t, string(b), "null")
+}
+
+func TestUnmarshal(t *testing.T) {
+	resolver := failure.CodeResolverFunc(func(s string) (failure.Code, bool) {
+		if s == "custom" {
+			return CustomCode(s), true
+		}
+		return nil, false
+	})
+
+	tests := map[string]struct {
+		err      error
+		wantCode failure.Code
+	}{
+		"translate": {
+			err:      failure.Translate(failure.New(TestCodeA, failure.Message("xxx"), failure.Context{"zzz": "true"}), TestCodeB),
+			wantCode: TestCodeB,
+		},
+		"custom code": {
+			err:      failure.New(CustomCode("custom")),
+			wantCode: CustomCode("custom"),
+		},
+		"ordered context": {
+			err:      failure.New(TestCodeA, failure.OrderedContext{{"b", "1"}, {"a", "2"}}),
+			wantCode: TestCodeA,
+		},
+		"wrap": {
+			err:      failure.Wrap(fmt.Errorf("aaa: %w", io.EOF)),
+			wantCode: nil,
+		},
+		"unexpected": {
+			err:      failure.Unexpected("xxx", failure.Message("yyy")),
+			wantCode: nil,
+		},
+		"mark unexpected": {
+			err:      failure.MarkUnexpected(failure.New(TestCodeA)),
+			wantCode: nil,
+		},
+		"join": {
+			err:      failure.Wrap(errors.Join(io.EOF, failure.New(TestCodeA, failure.Message("xxx")))),
+			wantCode: TestCodeA,
+		},
+	}
+
+	for title, test := range tests {
+		t.Run(title, func(t *testing.T) {
+			b, e := failure.Marshal(test.err)
+			shouldEqual(t, e, nil)
+
+			var err error
+			shouldEqual(t, failure.Unmarshal(b, &err, resolver), nil)
+
+			shouldEqual(t, err.Error(), test.err.Error())
+			shouldEqual(t, failure.CauseOf(err).Error(), failure.CauseOf(test.err).Error())
+
+			code, ok := failure.CodeOf(err)
+			shouldEqual(t, ok, test.wantCode != nil)
+			shouldEqual(t, code, test.wantCode)
+
+			wantMsg, wantOK := failure.MessageOf(test.err)
+			msg, ok := failure.MessageOf(err)
+			shouldEqual(t, ok, wantOK)
+			shouldEqual(t, msg, wantMsg)
+
+			wantCS, _ := failure.CallStackOf(test.err)
+			cs, ok := failure.CallStackOf(err)
+			shouldEqual(t, ok, true)
+			shouldEqual(t, fmt.Sprintf("%+v", cs), fmt.Sprintf("%+v", wantCS))
+			shouldEqual(t, fmt.Sprintf("%v", cs), fmt.Sprintf("%v", wantCS))
+
+			var want, got failure.StringTracer
+			failure.Trace(test.err, &want)
+			failure.Trace(err, &got)
+			shouldEqual(t, got, want)
+
+			b2, e := failure.Marshal(err)
+			shouldEqual(t, e, nil)
+			shouldEqual(t, string(b2), string(b))
+		})
+	}
+
+	err := io.EOF
+	shouldEqual(t, failure.Unmarshal([]byte("null"), &err, nil), nil)
+	shouldEqual(t, err, nil)
+
+	e := failure.Unmarshal([]byte(`{"chain":[{"unexpected":true,"error":"xxx"},{"code":"a"}]}`), &err, nil)
+	shouldDiffer(t, e, nil)
+}
+
+func TestMarshal_TypedContext(t *testing.T) {
+	err := failure.Custom(io.EOF, failure.TypedContext{"n": 1, "f": func() {}, "s": []string{"a"}})
+
+	b, e := failure.Marshal(err)
+	shouldEqual(t, e, nil)
+	shouldMatch(t, string(b), `\{"typed_context":\{"f":"0x[0-9a-f]+","n":1,"s":\["a"\]\}\}`)
+
+	var decoded error
+	shouldEqual(t, failure.Unmarshal(b, &decoded, nil), nil)
+	v, ok := failure.ValueOf(decoded, "n")
+	shouldEqual(t, ok, true)
+	shouldEqual(t, v, float64(1))
+	shouldEqual(t, decoded.Error(), err.Error())
+}
+
+func TestUnmarshal_EmptyEntries(t *testing.T) {
+	tests := map[string]error{
+		"context":         failure.Wrap(io.EOF, failure.Context{}),
+		"ordered context": failure.Custom(io.EOF, failure.OrderedContext{}),
+		"typed context":   failure.Custom(io.EOF, failure.TypedContext{}),
+		"call stack":      failure.Custom(io.EOF, failure.WithCallStack(failure.NewCallStack(nil))),
+		"message":         failure.Custom(io.EOF, failure.Message("")),
+		"unexpected":      failure.Unexpected(""),
+	}
+
+	for title, err := range tests {
+		t.Run(title, func(t *testing.T) {
+			b, e := failure.Marshal(err)
+			shouldEqual(t, e, nil)
+
+			var decoded error
+			shouldEqual(t, failure.Unmarshal(b, &decoded, nil), nil)
+			shouldEqual(t, decoded.Error(), err.Error())
+			shouldEqual(t, failure.CauseOf(decoded).Error(), failure.CauseOf(err).Error())
+
+			b2, e := failure.Marshal(decoded)
+			shouldEqual(t, e, nil)
+			shouldEqual(t, string(b2), string(b))
+		})
+	}
+
+	var err error
+	for _, data := range []string{
+		`{"chain":[{}]}`,
+		`{"chain":[{"type":"*errors.errorString"}]}`,
+		`{"chain":[{"truncated":true}]}`,
+	} {
+		shouldContain(t, fmt.Sprint(failure.Unmarshal([]byte(data), &err, nil)), "failure: unknown entry at 0 in the chain")
+	}
+	shouldEqual(t, err, nil)
+}
+
+func TestUnmarshal_NilCode(t *testing.T) {
+	err := failure.New(nil)
+	b, e := failure.Marshal(err)
+	shouldEqual(t, e, nil)
+	shouldContain(t, string(b), `{"code":null}`)
+
+	var decoded error
+	shouldEqual(t, failure.Unmarshal(b, &decoded, nil), nil)
+	code, ok := failure.CodeOf(decoded)
+	shouldEqual(t, code, nil)
+	shouldEqual(t, ok, false)
+	shouldEqual(t, decoded.Error(), err.Error())
+
+	_, e = failure.Marshal(failure.New((*pointerCode)(nil)))
+	shouldEqual(t, fmt.Sprint(e), "failure: nil pointer code of type *failure_test.pointerCode")
+	_, e = failure.Marshal(errors.Join(io.EOF, failure.New((*pointerCode)(nil))))
+	shouldDiffer(t, e, nil)
+}
@@ -0,0 +1,97 @@
+package failure
+
+// Layer is a group of wrappers added at the same place.
+// It is the same grouping as %+v of errors prints.
+type Layer struct {
+	// Branch is the path of the branch of multiple errors which the layer
+	// belongs to (e.g. "1.2"). It is empty outside of multiple errors.
+	Branch string
+	// Frame is the head frame of the call stack of the layer.
+	// It is nil if the layer has no call stack.
+	Frame Frame
+	// Code is the code added in the layer.
+	Code Code
+	// Messages are the messages added in the layer from outer to inner.
+	Messages []string
+	// PublicMessage is the outermost PublicMessage added in the layer.
+	PublicMessage string
+	// Context is the merged context added in the layer.
+	// The outer context takes precedence.
+	Context Context
+	// Unexpected is true if the layer marks the error unexpected.
+	Unexpected bool
+}
+
+func (l *Layer) isZero() bool {
+	return l.Frame == nil && l.Code == nil && len(l.Messages) == 0 && l.PublicMessage == "" && len(l.Context) == 0 && !l.Unexpected
+}
+
+// Layers returns layers of the err from outer to inner.
+// A new layer starts at each call stack in the chain, and at each branch
+// of multiple errors.
+// Layers without any information are omitted.
+func Layers(err error) []Layer {
+	type formatter interface {
+		IsFormatter()
+	}
+
+	var (
+		ls []Layer
+		l  Layer
+	)
+	flush := func() {
+		if !l.isZero() {
+			ls = append(ls, l)
+		}
+		l = Layer{Branch: l.Branch}
+	}
+
+	walkChain(err, chainVisitor{
+		enter: func(branch string) {
+			flush()
+			l = Layer{Branch: branch}
+		},
+		visit: func(n chainNode) {
+			i := &Iterator{err: n.err}
+			var (
+				cs   CallStack
+				ctx  OrderedContext
+				msg  Messenger
+				pub  PublicMessage
+				code Code
+			)
+			switch _, isFormatter := n.err.(formatter); {
+			case isFormatter:
+			case i.As(&cs):
+				flush()
+				l.Frame = cs.HeadFrame()
+			case i.As(&ctx):
+				if l.Context == nil {
+					l.Context = Context{}
+				}
+				for _, kv := range ctx {
+					if _, ok := l.Context[kv.Key]; !ok {
+						l.Context[kv.Key] = kv.Value
+					}
+				}
+			case i.As(&msg):
+				l.Messages = append(l.Messages, msg.Message())
+			case i.As(&pub):
+				if l.PublicMessage == "" {
+					l.PublicMessage = pub.String()
+				}
+			case i.As(&code):
+				if l.Code == nil {
+					l.Code = code
+				}
+			}
+			if isUnexpected(n.err) {
+				l.Unexpected = true
+			}
+		},
+		leave: func(string) {
+			flush()
+		},
+	})
+	return ls
+}
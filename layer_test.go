@@ -0,0 +1,69 @@
+package failure_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/morikuni/failure"
+)
+
+func TestLayers(t *testing.T) {
+	err := failure.New(TestCodeA, failure.Message("aaa"), failure.Context{"a": "1"})
+	err = failure.Wrap(err, failure.Message("bbb"), failure.Context{"b": "2"}, failure.Context{"b": "3", "c": "4"})
+	err = failure.MarkUnexpected(err, failure.Message("ccc"), failure.Message("ddd"))
+
+	ls := failure.Layers(err)
+	shouldEqual(t, len(ls), 3)
+
+	shouldEqual(t, ls[0].Frame.Line(), 14)
+	shouldEqual(t, ls[0].Frame.Func(), "TestLayers")
+	shouldEqual(t, ls[0].Code, nil)
+	shouldEqual(t, ls[0].Messages, []string{"ccc", "ddd"})
+	shouldEqual(t, ls[0].Context, failure.Context(nil))
+	shouldEqual(t, ls[0].Unexpected, true)
+
+	shouldEqual(t, ls[1].Frame.Line(), 13)
+	shouldEqual(t, ls[1].Code, nil)
+	shouldEqual(t, ls[1].Messages, []string{"bbb"})
+	shouldEqual(t, ls[1].Context, failure.Context{"b": "2", "c": "4"})
+	shouldEqual(t, ls[1].Unexpected, false)
+
+	shouldEqual(t, ls[2].Frame.Line(), 12)
+	shouldEqual(t, ls[2].Code, TestCodeA)
+	shouldEqual(t, ls[2].Messages, []string{"aaa"})
+	shouldEqual(t, ls[2].Context, failure.Context{"a": "1"})
+	shouldEqual(t, ls[2].Unexpected, false)
+	for _, l := range ls {
+		shouldEqual(t, l.Branch, "")
+	}
+}
+
+func TestLayers_Branches(t *testing.T) {
+	errA := failure.New(TestCodeA)
+	errB := failure.Unexpected("xxx")
+	err := failure.Wrap(errors.Join(errA, errors.Join(io.EOF, errB)), failure.Message("aaa"))
+
+	ls := failure.Layers(err)
+	shouldEqual(t, len(ls), 3)
+
+	shouldEqual(t, ls[0].Branch, "")
+	shouldEqual(t, ls[0].Frame.Line(), 45)
+	shouldEqual(t, ls[0].Messages, []string{"aaa"})
+
+	shouldEqual(t, ls[1].Branch, "1")
+	shouldEqual(t, ls[1].Frame.Line(), 43)
+	shouldEqual(t, ls[1].Code, TestCodeA)
+
+	shouldEqual(t, ls[2].Branch, "2.2")
+	shouldEqual(t, ls[2].Frame.Line(), 44)
+	shouldEqual(t, ls[2].Unexpected, true)
+
+	shouldEqual(t, failure.Layers(nil), []failure.Layer(nil))
+	shouldEqual(t, failure.Layers(io.EOF), []failure.Layer(nil))
+
+	ls = failure.Layers(failure.Custom(io.EOF, failure.Message("aaa")))
+	shouldEqual(t, len(ls), 1)
+	shouldEqual(t, ls[0].Frame, nil)
+	shouldEqual(t, ls[0].Messages, []string{"aaa"})
+}
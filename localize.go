@@ -0,0 +1,413 @@
+package failure
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Catalog provides message templates for each locale.
+//
+// A template can refer to values of Context and TypedContext of the error
+// by the key in braces, and can select plural forms by the PluralRule of
+// the locale.
+//
+//	"The user {user_id} is not found."
+//	"{count, plural, =0 {No files} one {# file} other {# files}} are locked."
+//
+// "#" in a plural form is replaced by the number, and "'{" and "'}" are
+// literal braces.
+type Catalog interface {
+	// Message returns the template of the key for the locale.
+	// The key is a MessageID or the string of a code.
+	Message(locale, key string) (string, bool)
+}
+
+var _ Catalog = MapCatalog(nil)
+
+// MapCatalog is an in-memory Catalog which maps locales to keys and
+// templates.
+//
+//	failure.MapCatalog{
+//		"en": {"NotFound": "The user {user_id} is not found."},
+//		"ja": {"NotFound": "ユーザー{user_id}は見つかりません。"},
+//	}
+type MapCatalog map[string]map[string]string
+
+// Message implements the Catalog interface.
+func (c MapCatalog) Message(locale, key string) (string, bool) {
+	msg, ok := c[locale][key]
+	return msg, ok
+}
+
+// ParseJSONCatalog parses a JSON catalog which has the same structure
+// as MapCatalog.
+//
+//	{"en": {"NotFound": "The user {user_id} is not found."}}
+func ParseJSONCatalog(r io.Reader) (MapCatalog, error) {
+	var c MapCatalog
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadJSONCatalog loads a JSON catalog file parsed by ParseJSONCatalog.
+func LoadJSONCatalog(filename string) (MapCatalog, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	c, err := ParseJSONCatalog(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failure: failed to parse catalog %s: %w", filename, err)
+	}
+	return c, nil
+}
+
+// MessageID is a wrapper which appends an ID of the localized message to
+// an error. LocalizedMessageOf looks up the ID before the code.
+type MessageID string
+
+// WrapError implements the Wrapper interface.
+func (id MessageID) WrapError(err error) error {
+	return &withMessageID{id, err}
+}
+
+type withMessageID struct {
+	id         MessageID
+	underlying error
+}
+
+func (w *withMessageID) Error() string {
+	return w.underlying.Error()
+}
+
+func (w *withMessageID) Unwrap() error {
+	return w.underlying
+}
+
+func (w *withMessageID) As(x interface{}) bool {
+	switch t := x.(type) {
+	case *MessageID:
+		*t = w.id
+		return true
+	case *Tracer:
+		(*t).Push(w.id)
+		return true
+	}
+	return false
+}
+
+// PluralCategory is a plural category of CLDR.
+type PluralCategory string
+
+// Plural categories.
+const (
+	PluralZero  PluralCategory = "zero"
+	PluralOne   PluralCategory = "one"
+	PluralTwo   PluralCategory = "two"
+	PluralFew   PluralCategory = "few"
+	PluralMany  PluralCategory = "many"
+	PluralOther PluralCategory = "other"
+)
+
+// PluralRule returns the plural category of the number.
+type PluralRule func(n float64) PluralCategory
+
+// pluralOneOther is the rule of English and many European languages.
+func pluralOneOther(n float64) PluralCategory {
+	if n == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+func pluralOtherOnly(float64) PluralCategory {
+	return PluralOther
+}
+
+// defaultPluralRules are PluralRules of languages used if a Localizer has
+// no rule for them.
+var defaultPluralRules = map[string]PluralRule{
+	"ja": pluralOtherOnly,
+	"ko": pluralOtherOnly,
+	"zh": pluralOtherOnly,
+}
+
+// Localizer localizes messages of errors with a Catalog.
+//
+//	l := &failure.Localizer{Catalog: catalog, DefaultLocale: "en"}
+//	msg, ok := l.MessageOf(err, "ja-JP")
+type Localizer struct {
+	// Catalog provides the templates of messages.
+	Catalog Catalog
+	// DefaultLocale is used if the Catalog has no message for the
+	// requested locale.
+	DefaultLocale string
+	// PluralRules are PluralRules of languages (e.g. "en", "pt-BR").
+	// The rule of a language is also used for its regional locales.
+	// Languages without a rule use the built-in rule if any, or the rule
+	// of English, which is "one" for 1 and "other" for the others.
+	PluralRules map[string]PluralRule
+}
+
+func (l *Localizer) pluralRule(locale string) PluralRule {
+	for _, loc := range fallbackLocales(locale) {
+		if r, ok := l.PluralRules[loc]; ok && r != nil {
+			return r
+		}
+		if r, ok := defaultPluralRules[loc]; ok {
+			return r
+		}
+	}
+	return pluralOneOther
+}
+
+// fallbackLocales returns the locale and its parents.
+// e.g. zh-Hant-TW -> [zh-Hant-TW zh-Hant zh]
+func fallbackLocales(locale string) []string {
+	locale = strings.Replace(locale, "_", "-", -1)
+	var locales []string
+	for locale != "" {
+		locales = append(locales, locale)
+		i := strings.LastIndexByte(locale, '-')
+		if i == -1 {
+			break
+		}
+		locale = locale[:i]
+	}
+	return locales
+}
+
+// LocalizedMessageOf returns the message of the err localized for the
+// locale by the catalog.
+// It is a shorthand of Localizer.MessageOf without DefaultLocale and
+// PluralRules.
+func LocalizedMessageOf(err error, catalog Catalog, locale string) (string, bool) {
+	l := &Localizer{Catalog: catalog}
+	return l.MessageOf(err, locale)
+}
+
+// MessageOf returns the message of the err localized for the locale.
+// The Catalog is looked up by MessageID added for the code of the err,
+// and then by the code and its ancestors, for each locale in the fallback
+// chain, such as ja-JP, ja and the DefaultLocale.
+// The message is rendered with Context and TypedContext of the err, in
+// which the outer one takes precedence and sensitive values are redacted.
+func (l *Localizer) MessageOf(err error, locale string) (string, bool) {
+	if err == nil || l.Catalog == nil {
+		return "", false
+	}
+
+	var keys []string
+	i := NewIterator(err)
+	for i.Next() {
+		var (
+			id   MessageID
+			code Code
+		)
+		if i.As(&id) {
+			keys = append(keys, string(id))
+		}
+		// IDs inside of the code are for another code.
+		if i.As(&code) {
+			break
+		}
+		if isUnexpected(i.Error()) {
+			break
+		}
+	}
+	if code, ok := CodeOf(err); ok {
+		for _, c := range lineageOf(code) {
+			// Invalid codes (e.g. nil pointers) have no message.
+			if s, err := ErrorCodeOf(c); err == nil {
+				keys = append(keys, s)
+			}
+		}
+	}
+
+	locales := fallbackLocales(locale)
+	if l.DefaultLocale != "" {
+		locales = append(locales, l.DefaultLocale)
+	}
+	for _, loc := range locales {
+		for _, key := range keys {
+			if tmpl, ok := l.Catalog.Message(loc, key); ok {
+				return renderMessage(tmpl, contextValues(err), l.pluralRule(loc)), true
+			}
+		}
+	}
+	return "", false
+}
+
+// contextValues returns values of all contexts in the err.
+// The outer context takes precedence.
+func contextValues(err error) map[string]interface{} {
+	values := map[string]interface{}{}
+	i := NewIterator(err)
+	for i.Next() {
+		var (
+			tc  TypedContext
+			ctx Context
+		)
+		switch {
+		case i.As(&tc):
+			for k, v := range tc {
+				if _, ok := values[k]; !ok {
+					values[k] = v
+				}
+			}
+		case i.As(&ctx):
+			for k, v := range ctx {
+				if _, ok := values[k]; !ok {
+					values[k] = v
+				}
+			}
+		}
+	}
+	return values
+}
+
+// renderMessage renders the template. Unknown keys and malformed
+// arguments are left as they are.
+func renderMessage(tmpl string, values map[string]interface{}, rule PluralRule) string {
+	var b strings.Builder
+	for i := 0; i < len(tmpl); i++ {
+		c := tmpl[i]
+		switch {
+		case c == '\'' && i+1 < len(tmpl) && (tmpl[i+1] == '{' || tmpl[i+1] == '}'):
+			b.WriteByte(tmpl[i+1])
+			i++
+		case c == '{':
+			end := matchingBrace(tmpl, i)
+			if end == -1 {
+				b.WriteString(tmpl[i:])
+				return b.String()
+			}
+			arg := tmpl[i : end+1]
+			if s, ok := renderArgument(arg[1:len(arg)-1], values, rule); ok {
+				b.WriteString(s)
+			} else {
+				b.WriteString(arg)
+			}
+			i = end
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// matchingBrace returns the index of '}' matching '{' at the start.
+func matchingBrace(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// renderArgument renders "key" or "key, plural, forms".
+func renderArgument(arg string, values map[string]interface{}, rule PluralRule) (string, bool) {
+	parts := strings.SplitN(arg, ",", 3)
+	key := strings.TrimSpace(parts[0])
+	v, ok := values[key]
+	if !ok {
+		return "", false
+	}
+	if len(parts) == 1 {
+		return fmt.Sprint(v), true
+	}
+	if len(parts) != 3 || strings.TrimSpace(parts[1]) != "plural" {
+		return "", false
+	}
+
+	n, ok := toNumber(v)
+	if !ok {
+		return "", false
+	}
+	forms, ok := parsePluralForms(parts[2])
+	if !ok {
+		return "", false
+	}
+	form, ok := forms["="+strconv.FormatFloat(n, 'f', -1, 64)]
+	if !ok {
+		form, ok = forms[string(rule(n))]
+	}
+	if !ok {
+		form, ok = forms[string(PluralOther)]
+	}
+	if !ok {
+		return "", false
+	}
+	num := strconv.FormatFloat(n, 'f', -1, 64)
+	return renderMessage(strings.Replace(form, "#", num, -1), values, rule), true
+}
+
+// parsePluralForms parses "=0 {...} one {...} other {...}".
+func parsePluralForms(s string) (map[string]string, bool) {
+	forms := map[string]string{}
+	for {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return forms, len(forms) != 0
+		}
+		open := strings.IndexByte(s, '{')
+		if open <= 0 {
+			return nil, false
+		}
+		end := matchingBrace(s, open)
+		if end == -1 {
+			return nil, false
+		}
+		forms[strings.TrimSpace(s[:open])] = s[open+1 : end]
+		s = s[end+1:]
+	}
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case int:
+		return float64(t), true
+	case int8:
+		return float64(t), true
+	case int16:
+		return float64(t), true
+	case int32:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	case uint:
+		return float64(t), true
+	case uint8:
+		return float64(t), true
+	case uint16:
+		return float64(t), true
+	case uint32:
+		return float64(t), true
+	case uint64:
+		return float64(t), true
+	case float32:
+		return float64(t), true
+	case float64:
+		return t, true
+	case string:
+		f, err := strconv.ParseFloat(t, 64)
+		return f, err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+	}
+	return 0, false
+}
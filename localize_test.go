@@ -0,0 +1,211 @@
+package failure_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/morikuni/failure"
+)
+
+func TestLocalizedMessageOf(t *testing.T) {
+	catalog := failure.MapCatalog{
+		"en": {
+			"code_a":        "A happened.",
+			"1":             "B happened to {user_id}.",
+			"user.locked":   "{count, plural, =0 {No files are} one {# file is} other {# files are}} locked by {user_id}.",
+			"user.password": "Wrong password {password}.",
+		},
+		"ja": {
+			"1":           "{user_id}にBが発生しました。",
+			"user.locked": "{count, plural, other {#個のファイル}}が{user_id}にロックされています。",
+		},
+		"ja-JP": {
+			"code_a": "Aが発生しました。",
+		},
+	}
+	l := &failure.Localizer{Catalog: catalog, DefaultLocale: "en"}
+
+	tests := map[string]struct {
+		err     error
+		locale  string
+		wantMsg string
+		wantOK  bool
+	}{
+		"code": {
+			err:     failure.New(TestCodeA),
+			locale:  "en",
+			wantMsg: "A happened.",
+			wantOK:  true,
+		},
+		"region": {
+			err:     failure.New(TestCodeA),
+			locale:  "ja-JP",
+			wantMsg: "Aが発生しました。",
+			wantOK:  true,
+		},
+		"fallback to language": {
+			err:     failure.New(TestCodeB, failure.Context{"user_id": "gopher"}),
+			locale:  "ja-JP",
+			wantMsg: "gopherにBが発生しました。",
+			wantOK:  true,
+		},
+		"fallback to default": {
+			err:     failure.New(TestCodeA),
+			locale:  "fr_CA",
+			wantMsg: "A happened.",
+			wantOK:  true,
+		},
+		"outer context": {
+			err:     failure.Wrap(failure.New(TestCodeB, failure.Context{"user_id": "inner"}), failure.Context{"user_id": "outer"}),
+			locale:  "en",
+			wantMsg: "B happened to outer.",
+			wantOK:  true,
+		},
+		"unknown key": {
+			err:     failure.New(TestCodeB),
+			locale:  "en",
+			wantMsg: "B happened to {user_id}.",
+			wantOK:  true,
+		},
+		"message id": {
+			err:     failure.New(TestCodeA, failure.MessageID("user.locked"), failure.TypedContext{"count": 1, "user_id": "gopher"}),
+			locale:  "en",
+			wantMsg: "1 file is locked by gopher.",
+			wantOK:  true,
+		},
+		"plural other": {
+			err:     failure.New(TestCodeA, failure.MessageID("user.locked"), failure.Context{"count": "3", "user_id": "gopher"}),
+			locale:  "en",
+			wantMsg: "3 files are locked by gopher.",
+			wantOK:  true,
+		},
+		"plural exact": {
+			err:     failure.New(TestCodeA, failure.MessageID("user.locked"), failure.TypedContext{"count": 0, "user_id": "gopher"}),
+			locale:  "en",
+			wantMsg: "No files are locked by gopher.",
+			wantOK:  true,
+		},
+		"plural ja": {
+			err:     failure.New(TestCodeA, failure.MessageID("user.locked"), failure.TypedContext{"count": 1, "user_id": "gopher"}),
+			locale:  "ja",
+			wantMsg: "1個のファイルがgopherにロックされています。",
+			wantOK:  true,
+		},
+		"message id for another code": {
+			err:     failure.Translate(failure.New(TestCodeA, failure.MessageID("user.locked")), TestCodeB, failure.Context{"user_id": "gopher"}),
+			locale:  "en",
+			wantMsg: "B happened to gopher.",
+			wantOK:  true,
+		},
+		"sensitive": {
+			err:     failure.New(TestCodeA, failure.MessageID("user.password"), failure.SensitiveContext{"password": "secret"}),
+			locale:  "en",
+			wantMsg: "Wrong password [REDACTED].",
+			wantOK:  true,
+		},
+		"unknown code": {
+			err:     failure.New(failure.StringCode("unknown")),
+			locale:  "en",
+			wantMsg: "",
+			wantOK:  false,
+		},
+		"unexpected": {
+			err:     failure.MarkUnexpected(failure.New(TestCodeA)),
+			locale:  "en",
+			wantMsg: "",
+			wantOK:  false,
+		},
+		"nil": {
+			err:     nil,
+			locale:  "en",
+			wantMsg: "",
+			wantOK:  false,
+		},
+	}
+
+	for title, test := range tests {
+		t.Run(title, func(t *testing.T) {
+			msg, ok := l.MessageOf(test.err, test.locale)
+			shouldEqual(t, msg, test.wantMsg)
+			shouldEqual(t, ok, test.wantOK)
+		})
+	}
+
+	// No default locale.
+	msg, ok := failure.LocalizedMessageOf(failure.New(TestCodeA), catalog, "ja-JP")
+	shouldEqual(t, msg, "Aが発生しました。")
+	shouldEqual(t, ok, true)
+	msg, ok = failure.LocalizedMessageOf(failure.New(TestCodeA), catalog, "fr")
+	shouldEqual(t, msg, "")
+	shouldEqual(t, ok, false)
+	msg, ok = failure.LocalizedMessageOf(failure.New(TestCodeA), nil, "en")
+	shouldEqual(t, msg, "")
+	shouldEqual(t, ok, false)
+}
+
+func TestLocalizer_PluralRules(t *testing.T) {
+	l := &failure.Localizer{
+		Catalog: failure.MapCatalog{
+			"pl": {"code_a": "{n, plural, one {# plik} few {# pliki} other {# plików}}"},
+		},
+		PluralRules: map[string]failure.PluralRule{
+			"pl": func(n float64) failure.PluralCategory {
+				switch i := int(n); {
+				case i == 1:
+					return failure.PluralOne
+				case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+					return failure.PluralFew
+				}
+				return failure.PluralOther
+			},
+		},
+	}
+
+	for n, want := range map[int]string{1: "1 plik", 3: "3 pliki", 5: "5 plików", 22: "22 pliki"} {
+		msg, _ := l.MessageOf(failure.New(TestCodeA, failure.TypedContext{"n": n}), "pl-PL")
+		shouldEqual(t, msg, want)
+	}
+}
+
+func TestLoadJSONCatalog(t *testing.T) {
+	c, err := failure.ParseJSONCatalog(strings.NewReader(`{"en": {"code_a": "A happened."}}`))
+	shouldEqual(t, err, nil)
+	shouldEqual(t, c, failure.MapCatalog{"en": {"code_a": "A happened."}})
+
+	filename := filepath.Join(t.TempDir(), "catalog.json")
+	shouldEqual(t, os.WriteFile(filename, []byte(`{"ja": {"code_a": "Aが発生しました。"}}`), 0o644), nil)
+	c, err = failure.LoadJSONCatalog(filename)
+	shouldEqual(t, err, nil)
+	msg, ok := c.Message("ja", "code_a")
+	shouldEqual(t, ok, true)
+	shouldEqual(t, msg, "Aが発生しました。")
+
+	shouldEqual(t, os.WriteFile(filename, []byte(`{`), 0o644), nil)
+	_, err = failure.LoadJSONCatalog(filename)
+	shouldContain(t, fmt.Sprint(err), "failure: failed to parse catalog")
+}
+
+func TestMessageID(t *testing.T) {
+	err := failure.New(TestCodeA, failure.MessageID("user.locked"))
+	shouldEqual(t, err.Error(), "failure_test.TestMessageID: code(code_a)")
+	shouldContain(t, fmt.Sprintf("%+v", err), "    message_id(\"user.locked\")\n")
+
+	b, e := failure.Marshal(err)
+	shouldEqual(t, e, nil)
+	shouldContain(t, string(b), `{"message_id":"user.locked"}`)
+}
+
+func TestLocalizedMessageOf_NilPointerCode(t *testing.T) {
+	catalog := failure.MapCatalog{"en": {"user.locked": "Locked."}}
+
+	msg, ok := failure.LocalizedMessageOf(failure.New((*pointerCode)(nil)), catalog, "en")
+	shouldEqual(t, msg, "")
+	shouldEqual(t, ok, false)
+
+	msg, ok = failure.LocalizedMessageOf(failure.New((*pointerCode)(nil), failure.MessageID("user.locked")), catalog, "en")
+	shouldEqual(t, msg, "Locked.")
+	shouldEqual(t, ok, true)
+}
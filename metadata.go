@@ -0,0 +1,253 @@
+package failure
+
+import "strconv"
+
+// RetryableCode is a Code which tells whether the operation may succeed by
+// retrying. It is used by IsRetryable.
+type RetryableCode interface {
+	Code
+	// Retryable reports whether errors of the code are retryable.
+	Retryable() bool
+}
+
+// SeverityCode is a Code which has a severity. It is used by SeverityOf.
+type SeverityCode interface {
+	Code
+	// Severity returns the severity of errors of the code.
+	// SeverityUnspecified means the code has no severity.
+	Severity() Severity
+}
+
+// HTTPStatusCode is a Code which has an HTTP status code.
+// It is used by HTTPStatusOf.
+type HTTPStatusCode interface {
+	Code
+	// HTTPStatus returns the HTTP status code for errors of the code.
+	// 0 means the code has no HTTP status code.
+	HTTPStatus() int
+}
+
+// DocumentedCode is a Code which has a URL of the document (e.g. runbook).
+// It is used by DocURLOf.
+type DocumentedCode interface {
+	Code
+	// DocURL returns the URL of the document for errors of the code.
+	// Empty string means the code has no document.
+	DocURL() string
+}
+
+// Severity is a severity of errors, which is typically used as a log level.
+type Severity int
+
+// Severities in the ascending order.
+const (
+	SeverityUnspecified Severity = iota
+	SeverityDebug
+	SeverityInfo
+	SeverityWarning
+	SeverityError
+	SeverityCritical
+)
+
+// String implements the fmt.Stringer interface.
+func (s Severity) String() string {
+	switch s {
+	case SeverityUnspecified:
+		return "UNSPECIFIED"
+	case SeverityDebug:
+		return "DEBUG"
+	case SeverityInfo:
+		return "INFO"
+	case SeverityWarning:
+		return "WARNING"
+	case SeverityError:
+		return "ERROR"
+	case SeverityCritical:
+		return "CRITICAL"
+	}
+	return "SEVERITY(" + strconv.Itoa(int(s)) + ")"
+}
+
+// IsRetryable reports whether the code of the err is RetryableCode and
+// is retryable. If the code does not tell, its ancestors are used.
+func IsRetryable(err error) bool {
+	c, ok := CodeOf(err)
+	if !ok {
+		return false
+	}
+	for _, c := range lineageOf(c) {
+		if dc, ok := c.(DefinedCode); ok && dc.retryable == unset {
+			continue
+		}
+		if rc, ok := c.(RetryableCode); ok {
+			return rc.Retryable()
+		}
+	}
+	return false
+}
+
+// SeverityOf returns the severity of the code of the err.
+// If the code has no severity, its ancestors are used.
+// It returns false if the err has no code or no severity is found.
+func SeverityOf(err error) (Severity, bool) {
+	c, ok := CodeOf(err)
+	if !ok {
+		return SeverityUnspecified, false
+	}
+	for _, c := range lineageOf(c) {
+		if sc, ok := c.(SeverityCode); ok && sc.Severity() != SeverityUnspecified {
+			return sc.Severity(), true
+		}
+	}
+	return SeverityUnspecified, false
+}
+
+// HTTPStatusOf returns the HTTP status code of the code of the err.
+// If the code has no HTTP status code, its ancestors are used.
+// It returns false if the err has no code or no HTTP status code is found.
+func HTTPStatusOf(err error) (int, bool) {
+	c, ok := CodeOf(err)
+	if !ok {
+		return 0, false
+	}
+	for _, c := range lineageOf(c) {
+		if s, ok := CodeHTTPStatus(c); ok {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+// CodeHTTPStatus returns the HTTP status code of the code itself without
+// its ancestors.
+func CodeHTTPStatus(code Code) (int, bool) {
+	if hc, ok := code.(HTTPStatusCode); ok && hc.HTTPStatus() != 0 {
+		return hc.HTTPStatus(), true
+	}
+	return 0, false
+}
+
+// DocURLOf returns the URL of the document of the code of the err.
+// If the code has no document, its ancestors are used.
+// It returns false if the err has no code or no document is found.
+func DocURLOf(err error) (string, bool) {
+	c, ok := CodeOf(err)
+	if !ok {
+		return "", false
+	}
+	for _, c := range lineageOf(c) {
+		if dc, ok := c.(DocumentedCode); ok && dc.DocURL() != "" {
+			return dc.DocURL(), true
+		}
+	}
+	return "", false
+}
+
+var (
+	_ RetryableCode  = DefinedCode{}
+	_ SeverityCode   = DefinedCode{}
+	_ HTTPStatusCode = DefinedCode{}
+	_ DocumentedCode = DefinedCode{}
+	_ ChildCode      = DefinedCode{}
+)
+
+// DefineCode returns a code of the string with metadata built by methods.
+// The code is compared by == operator including the metadata, so it
+// should be defined once as a package variable.
+//
+//	var NotFound = failure.DefineCode("not_found").
+//		WithSeverity(failure.SeverityInfo).
+//		WithHTTPStatus(http.StatusNotFound).
+//		WithDocURL("https://example.com/runbooks/not_found")
+func DefineCode(code string) DefinedCode {
+	return DefinedCode{code: code}
+}
+
+// DefinedCode is a code created by DefineCode.
+// It implements RetryableCode, SeverityCode, HTTPStatusCode,
+// DocumentedCode and ChildCode. Metadata which is not set falls back to
+// the parent. It also implements the error interface like StringCode.
+type DefinedCode struct {
+	code       string
+	parent     Code
+	retryable  tristate
+	severity   Severity
+	httpStatus int
+	docURL     string
+}
+
+type tristate int8
+
+const (
+	unset tristate = iota
+	yes
+	no
+)
+
+// WithParent returns a copy of the code with the parent code.
+func (c DefinedCode) WithParent(parent Code) DefinedCode {
+	c.parent = parent
+	return c
+}
+
+// WithRetryable returns a copy of the code with the retryability.
+func (c DefinedCode) WithRetryable(retryable bool) DefinedCode {
+	c.retryable = no
+	if retryable {
+		c.retryable = yes
+	}
+	return c
+}
+
+// WithSeverity returns a copy of the code with the severity.
+func (c DefinedCode) WithSeverity(severity Severity) DefinedCode {
+	c.severity = severity
+	return c
+}
+
+// WithHTTPStatus returns a copy of the code with the HTTP status code.
+func (c DefinedCode) WithHTTPStatus(status int) DefinedCode {
+	c.httpStatus = status
+	return c
+}
+
+// WithDocURL returns a copy of the code with the URL of the document.
+func (c DefinedCode) WithDocURL(url string) DefinedCode {
+	c.docURL = url
+	return c
+}
+
+// ErrorCode implements the Code interface.
+func (c DefinedCode) ErrorCode() string {
+	return c.code
+}
+
+// Error implements the error interface.
+func (c DefinedCode) Error() string {
+	return c.code
+}
+
+// Parent implements the ChildCode interface.
+func (c DefinedCode) Parent() Code {
+	return c.parent
+}
+
+// Retryable implements the RetryableCode interface.
+func (c DefinedCode) Retryable() bool {
+	return c.retryable == yes
+}
+
+// Severity implements the SeverityCode interface.
+func (c DefinedCode) Severity() Severity {
+	return c.severity
+}
+
+// HTTPStatus implements the HTTPStatusCode interface.
+func (c DefinedCode) HTTPStatus() int {
+	return c.httpStatus
+}
+
+// DocURL implements the DocumentedCode interface.
+func (c DefinedCode) DocURL() string {
+	return c.docURL
+}
@@ -0,0 +1,88 @@
+package failure_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/morikuni/failure"
+)
+
+var TestDefinedNotFound = failure.DefineCode("not_found").
+	WithSeverity(failure.SeverityInfo).
+	WithHTTPStatus(http.StatusNotFound).
+	WithDocURL("https://example.com/runbooks/not_found")
+
+var TestDefinedUnavailable = failure.DefineCode("unavailable").
+	WithRetryable(true).
+	WithSeverity(failure.SeverityError)
+
+func TestDefineCode(t *testing.T) {
+	shouldEqual(t, TestDefinedNotFound.ErrorCode(), "not_found")
+	shouldEqual(t, TestDefinedNotFound == failure.DefineCode("not_found"), false)
+	shouldEqual(t, TestDefinedNotFound.WithDocURL("") == TestDefinedNotFound, false)
+
+	err := failure.Translate(failure.New(TestCodeA), TestDefinedNotFound)
+	shouldEqual(t, failure.Is(err, TestDefinedNotFound), true)
+	shouldEqual(t, errors.Is(err, TestDefinedNotFound), true)
+	shouldEqual(t, errors.Is(err, TestDefinedUnavailable), false)
+
+	var rc failure.RetryableCode
+	shouldEqual(t, errors.As(err, &rc), true)
+	shouldEqual(t, rc, failure.RetryableCode(TestDefinedNotFound))
+}
+
+func TestMetadataOf(t *testing.T) {
+	tests := map[string]struct {
+		err           error
+		wantRetryable bool
+		wantSeverity  failure.Severity
+		wantStatus    int
+		wantDocURL    string
+	}{
+		"not found": {
+			err:           failure.New(TestDefinedNotFound),
+			wantRetryable: false,
+			wantSeverity:  failure.SeverityInfo,
+			wantStatus:    http.StatusNotFound,
+			wantDocURL:    "https://example.com/runbooks/not_found",
+		},
+		"unavailable": {
+			err:           failure.Translate(failure.New(TestDefinedNotFound), TestDefinedUnavailable),
+			wantRetryable: true,
+			wantSeverity:  failure.SeverityError,
+		},
+		"string code": {
+			err: failure.New(TestCodeA),
+		},
+		"unexpected": {
+			err: failure.MarkUnexpected(failure.New(TestDefinedUnavailable)),
+		},
+		"nil": {
+			err: nil,
+		},
+	}
+
+	for title, test := range tests {
+		t.Run(title, func(t *testing.T) {
+			shouldEqual(t, failure.IsRetryable(test.err), test.wantRetryable)
+
+			s, ok := failure.SeverityOf(test.err)
+			shouldEqual(t, s, test.wantSeverity)
+			shouldEqual(t, ok, test.wantSeverity != failure.SeverityUnspecified)
+
+			status, ok := failure.HTTPStatusOf(test.err)
+			shouldEqual(t, status, test.wantStatus)
+			shouldEqual(t, ok, test.wantStatus != 0)
+
+			u, ok := failure.DocURLOf(test.err)
+			shouldEqual(t, u, test.wantDocURL)
+			shouldEqual(t, ok, test.wantDocURL != "")
+		})
+	}
+}
+
+func TestSeverity_String(t *testing.T) {
+	shouldEqual(t, failure.SeverityWarning.String(), "WARNING")
+	shouldEqual(t, failure.Severity(10).String(), "SEVERITY(10)")
+}
@@ -0,0 +1,96 @@
+package failure
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// PayloadCode is a Code which carries data of the error in its value.
+// Codes of the same type and the same ErrorCode are the same kind of code,
+// and they match each other by Is and errors.Is regardless of their data.
+//
+//	type RateLimited struct {
+//		RetryAfter time.Duration
+//	}
+//
+//	func (RateLimited) ErrorCode() string { return "rate_limited" }
+//	func (RateLimited) IsPayloadCode()    {}
+//
+//	err := failure.New(RateLimited{RetryAfter: time.Second})
+//	failure.Is(err, RateLimited{}) // true
+//
+// Use CodeAs to extract the code with its data.
+// The data is printed by %+v and Tracer, and encoded by Marshal as JSON of
+// the code. Unmarshal decodes the data into the code resolved by the
+// CodeResolver, so register a value of the type (e.g. RateLimited{}) to
+// reconstruct it.
+type PayloadCode interface {
+	Code
+	// IsPayloadCode is a marker method of PayloadCode.
+	IsPayloadCode()
+}
+
+// sameKindCode reports whether a or b is a PayloadCode and they are the
+// same kind of code.
+func sameKindCode(a, b Code) (isPayload, same bool) {
+	_, pa := a.(PayloadCode)
+	_, pb := b.(PayloadCode)
+	if !pa && !pb {
+		return false, false
+	}
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return true, false
+	}
+	sa, errA := ErrorCodeOf(a)
+	sb, errB := ErrorCodeOf(b)
+	return true, errA == nil && errB == nil && sa == sb
+}
+
+// codeDetail returns the code for printing with its payload.
+func codeDetail(c Code) string {
+	if _, ok := c.(PayloadCode); !ok {
+		return codeString(c)
+	}
+	return fmt.Sprintf("%s %+v", codeString(c), c)
+}
+
+func encodePayload(c Code) json.RawMessage {
+	if _, ok := c.(PayloadCode); !ok {
+		return nil
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// decodePayload decodes the payload into a copy of the code.
+func decodePayload(c Code, payload json.RawMessage) (Code, error) {
+	if payload == nil {
+		return c, nil
+	}
+	if _, ok := c.(PayloadCode); !ok {
+		return c, nil
+	}
+	// Decode into a copy not to modify the resolved code.
+	cv := reflect.ValueOf(c)
+	if cv.Kind() == reflect.Ptr {
+		if cv.IsNil() {
+			return c, nil
+		}
+		v := reflect.New(cv.Type().Elem())
+		v.Elem().Set(cv.Elem())
+		if err := json.Unmarshal(payload, v.Interface()); err != nil {
+			return nil, fmt.Errorf("failure: failed to decode payload of code %q: %v", codeString(c), err)
+		}
+		return v.Interface().(Code), nil
+	}
+	v := reflect.New(cv.Type())
+	v.Elem().Set(cv)
+	if err := json.Unmarshal(payload, v.Interface()); err != nil {
+		return nil, fmt.Errorf("failure: failed to decode payload of code %q: %v", codeString(c), err)
+	}
+	return v.Elem().Interface().(Code), nil
+}
@@ -0,0 +1,80 @@
+package failure_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/morikuni/failure"
+)
+
+type RateLimited struct {
+	RetryAfter time.Duration
+}
+
+func (RateLimited) ErrorCode() string { return "rate_limited" }
+func (RateLimited) IsPayloadCode()    {}
+
+type ValidationFailed struct {
+	Fields []string
+}
+
+func (*ValidationFailed) ErrorCode() string { return "validation_failed" }
+func (*ValidationFailed) IsPayloadCode()    {}
+
+func TestPayloadCode_Is(t *testing.T) {
+	err := failure.New(RateLimited{RetryAfter: time.Second})
+
+	shouldEqual(t, failure.Is(err, RateLimited{}), true)
+	shouldEqual(t, failure.Is(err, RateLimited{RetryAfter: time.Minute}), true)
+	shouldEqual(t, failure.Is(err, failure.StringCode("rate_limited")), false)
+	shouldEqual(t, errors.Is(err, failure.New(RateLimited{})), false)
+
+	err = failure.New(&ValidationFailed{Fields: []string{"name"}})
+	shouldEqual(t, failure.Is(err, &ValidationFailed{}), true)
+	shouldEqual(t, failure.Is(err, RateLimited{}), false)
+}
+
+func TestPayloadCode_Format(t *testing.T) {
+	err := failure.New(RateLimited{RetryAfter: time.Second})
+
+	shouldEqual(t, err.Error(), "failure_test.TestPayloadCode_Format: code(rate_limited)")
+	shouldContain(t, fmt.Sprintf("%+v", err), "    code(rate_limited {RetryAfter:1s})\n")
+
+	var st failure.StringTracer
+	failure.Trace(err, &st)
+	shouldContain(t, fmt.Sprint(st), "code = rate_limited {RetryAfter:1s}")
+}
+
+func TestPayloadCode_JSON(t *testing.T) {
+	r := failure.NewCodeRegistry()
+	shouldEqual(t, r.Register(RateLimited{}, &ValidationFailed{}), nil)
+
+	tests := map[string]struct {
+		code failure.Code
+		json string
+	}{
+		"value":   {RateLimited{RetryAfter: time.Second}, `"payload":{"RetryAfter":1000000000}`},
+		"pointer": {&ValidationFailed{Fields: []string{"name"}}, `"payload":{"Fields":["name"]}`},
+	}
+	for title, test := range tests {
+		t.Run(title, func(t *testing.T) {
+			b, err := failure.Marshal(failure.New(test.code))
+			shouldEqual(t, err, nil)
+			shouldContain(t, string(b), test.json)
+
+			var decoded error
+			shouldEqual(t, failure.Unmarshal(b, &decoded, r), nil)
+			code, ok := failure.CodeOf(decoded)
+			shouldEqual(t, ok, true)
+			shouldEqual(t, code, test.code)
+		})
+	}
+
+	// The registered code is not modified.
+	shouldEqual(t, r.Codes(), []failure.Code{RateLimited{}, &ValidationFailed{}})
+
+	var decoded error
+	shouldDiffer(t, failure.Unmarshal([]byte(`{"chain":[{"code":"rate_limited","payload":"x"}]}`), &decoded, r), nil)
+}
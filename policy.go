@@ -0,0 +1,157 @@
+package failure
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+)
+
+// CallStackPolicy decides whether constructor functions such as New,
+// Translate, Wrap, Unexpected and MarkUnexpected capture a call stack.
+// Capturing call stack is relatively expensive, so errors returned very
+// frequently (e.g. NotFound) may skip it.
+type CallStackPolicy interface {
+	// CaptureCallStack reports whether to capture a call stack for an error
+	// with the code. The code is nil for errors without code.
+	CaptureCallStack(code Code) bool
+}
+
+// CallStackPolicyFunc is an adaptor to use function as the CallStackPolicy
+// interface.
+type CallStackPolicyFunc func(code Code) bool
+
+// CaptureCallStack implements the CallStackPolicy interface.
+func (f CallStackPolicyFunc) CaptureCallStack(code Code) bool {
+	return f(code)
+}
+
+type constPolicy bool
+
+func (p constPolicy) CaptureCallStack(Code) bool {
+	return bool(p)
+}
+
+var (
+	// CaptureAlways is a CallStackPolicy which always captures call stacks.
+	// This is the default policy.
+	CaptureAlways CallStackPolicy = constPolicy(true)
+	// CaptureNever is a CallStackPolicy which never captures call stacks.
+	CaptureNever CallStackPolicy = constPolicy(false)
+	// CaptureUnexpected is a CallStackPolicy which captures call stacks
+	// only for errors without code (e.g. Unexpected, MarkUnexpected).
+	// Errors of other packages wrapped by Wrap (e.g. Wrap(io.EOF)) also
+	// have no code, so they are regarded as unexpected and call stacks
+	// are captured for them.
+	CaptureUnexpected CallStackPolicy = CallStackPolicyFunc(func(code Code) bool {
+		return code == nil
+	})
+)
+
+// CaptureSampled returns a CallStackPolicy which captures call stacks at
+// the rate (0.0 to 1.0). It panics if the rate is out of the range.
+// The sampling uses the global source of math/rand, which is safe for
+// concurrent use.
+func CaptureSampled(rate float64) CallStackPolicy {
+	if math.IsNaN(rate) || rate < 0 || rate > 1 {
+		panic(fmt.Sprintf("failure: CaptureSampled: rate must be in [0, 1], got %v", rate))
+	}
+	return CallStackPolicyFunc(func(Code) bool {
+		return rand.Float64() < rate
+	})
+}
+
+type callStackPolicies struct {
+	global CallStackPolicy
+	codes  map[Code]CallStackPolicy
+}
+
+var (
+	// policies holds *callStackPolicies. It is replaced as a whole on
+	// update so that the hot path reads it without lock.
+	policies   atomic.Value
+	policiesMu sync.Mutex
+)
+
+func init() {
+	policies.Store(&callStackPolicies{global: CaptureAlways})
+}
+
+// SetCallStackPolicy sets the CallStackPolicy used for all codes.
+// If the policy is nil, CaptureAlways is used.
+func SetCallStackPolicy(policy CallStackPolicy) {
+	if policy == nil {
+		policy = CaptureAlways
+	}
+	updatePolicies(func(p *callStackPolicies) {
+		p.global = policy
+	})
+}
+
+// SetCodeCallStackPolicy sets the CallStackPolicy used for the code and
+// its descendants. It takes precedence over the policy set by
+// SetCallStackPolicy.
+// If the policy is nil, the policy for the code is removed.
+// It panics if the code cannot be a map key.
+func SetCodeCallStackPolicy(code Code, policy CallStackPolicy) {
+	mustBeComparableCode("SetCodeCallStackPolicy", code)
+	updatePolicies(func(p *callStackPolicies) {
+		if policy == nil {
+			delete(p.codes, code)
+		} else {
+			p.codes[code] = policy
+		}
+	})
+}
+
+func updatePolicies(f func(p *callStackPolicies)) {
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+
+	old := policies.Load().(*callStackPolicies)
+	p := &callStackPolicies{
+		global: old.global,
+		codes:  make(map[Code]CallStackPolicy, len(old.codes)),
+	}
+	for c, cp := range old.codes {
+		p.codes[c] = cp
+	}
+	f(p)
+	policies.Store(p)
+}
+
+// shouldCaptureCallStack reports whether to capture a call stack for the err.
+// If codeKnown is false, the code is extracted from the err only when
+// the policies need it.
+func shouldCaptureCallStack(err error, code Code, codeKnown bool) bool {
+	p := policies.Load().(*callStackPolicies)
+	if len(p.codes) == 0 {
+		if cp, ok := p.global.(constPolicy); ok {
+			return bool(cp)
+		}
+	}
+
+	if !codeKnown {
+		code, _ = CodeOf(err)
+	}
+	for _, c := range lineageOf(code) {
+		if cp, ok := p.lookup(c); ok {
+			return cp.CaptureCallStack(code)
+		}
+	}
+	return p.global.CaptureCallStack(code)
+}
+
+func (p *callStackPolicies) lookup(code Code) (CallStackPolicy, bool) {
+	if isMapKeyCode(code) {
+		cp, ok := p.codes[code]
+		return cp, ok
+	}
+	for c, cp := range p.codes {
+		if EqualCode(c, code) {
+			return cp, true
+		}
+	}
+	return nil, false
+}
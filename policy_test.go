@@ -0,0 +1,35 @@
+package failure_test
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/morikuni/failure"
+)
+
+func TestCaptureSampled_InvalidRate(t *testing.T) {
+	for _, rate := range []float64{-0.1, 1.1, math.NaN()} {
+		func() {
+			defer func() {
+				shouldContain(t, fmt.Sprint(recover()), "failure: CaptureSampled: rate must be in [0, 1]")
+			}()
+			failure.CaptureSampled(rate)
+		}()
+	}
+}
+
+func TestCallStackDepth_Custom(t *testing.T) {
+	// It has no effect on Custom.
+	shouldEqual(t, failure.Custom(io.EOF, failure.CallStackDepth(10)), io.EOF)
+	_, ok := failure.CallStackOf(failure.Custom(io.EOF, failure.CallStackDepth(10), failure.Message("xxx")))
+	shouldEqual(t, ok, false)
+
+	err := failure.Wrap(io.EOF, failure.CallStackDepth(1), failure.Message("xxx"))
+	cs, ok := failure.CallStackOf(err)
+	shouldEqual(t, ok, true)
+	shouldEqual(t, len(cs.Frames()), 1)
+	msg, _ := failure.MessageOf(err)
+	shouldEqual(t, msg, "xxx")
+}
@@ -0,0 +1,139 @@
+package failure
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// PublicMessage is a wrapper which appends a message for end users to an
+// error. Unlike Message, it is extracted only by PublicMessageOf, so that
+// API layers can show it while Message keeps internal details.
+//
+//	failure.New(NotFound, failure.PublicMessage("The user is not found."), failure.Message("user_id does not exist in users table"))
+type PublicMessage string
+
+// String returns underlying string message.
+func (m PublicMessage) String() string {
+	return string(m)
+}
+
+// WrapError implements the Wrapper interface.
+func (m PublicMessage) WrapError(err error) error {
+	return &withPublicMessage{m, err}
+}
+
+type withPublicMessage struct {
+	message    PublicMessage
+	underlying error
+}
+
+func (w *withPublicMessage) Error() string {
+	return fmt.Sprintf("%s: %s", w.message, w.underlying)
+}
+
+func (w *withPublicMessage) Unwrap() error {
+	return w.underlying
+}
+
+func (w *withPublicMessage) As(x interface{}) bool {
+	switch t := x.(type) {
+	case *PublicMessage:
+		*t = w.message
+		return true
+	case *Tracer:
+		(*t).Push(w.message)
+		return true
+	}
+	return false
+}
+
+var (
+	// publicMessages holds map[Code]string. It is replaced as a whole on
+	// update.
+	publicMessages   atomic.Value
+	publicMessagesMu sync.Mutex
+)
+
+func init() {
+	publicMessages.Store(map[Code]string{})
+}
+
+// SetDefaultPublicMessage sets the message returned by PublicMessageOf for
+// errors of the code without PublicMessage.
+// If the message is empty, the default message for the code is removed.
+// It panics if the code cannot be a map key.
+func SetDefaultPublicMessage(code Code, message string) {
+	mustBeComparableCode("SetDefaultPublicMessage", code)
+
+	publicMessagesMu.Lock()
+	defer publicMessagesMu.Unlock()
+
+	old := publicMessages.Load().(map[Code]string)
+	m := make(map[Code]string, len(old)+1)
+	for c, msg := range old {
+		m[c] = msg
+	}
+	if message == "" {
+		delete(m, code)
+	} else {
+		m[code] = message
+	}
+	publicMessages.Store(m)
+}
+
+// PublicMessageOf extracts a message for end users from the err.
+// It returns the outermost PublicMessage added for the code of the err,
+// that is PublicMessage added to the error with the code or its wrappers.
+// If there is no such message, the default message for the code set by
+// SetDefaultPublicMessage is returned. The default messages for the
+// ancestors of the code are also used.
+// It never returns Message or the text of Error().
+func PublicMessageOf(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+
+	i := NewIterator(err)
+	for i.Next() {
+		var (
+			msg  PublicMessage
+			code Code
+		)
+		if i.As(&msg) {
+			return string(msg), true
+		}
+		// Messages inside of the code are for another code.
+		if i.As(&code) {
+			break
+		}
+		if isUnexpected(i.Error()) {
+			break
+		}
+	}
+
+	code, ok := CodeOf(err)
+	if !ok {
+		return "", false
+	}
+	defaults := publicMessages.Load().(map[Code]string)
+	for _, c := range lineageOf(code) {
+		if msg, ok := lookupPublicMessage(defaults, c); ok {
+			return msg, true
+		}
+	}
+	return "", false
+}
+
+func lookupPublicMessage(defaults map[Code]string, code Code) (string, bool) {
+	if isMapKeyCode(code) {
+		msg, ok := defaults[code]
+		return msg, ok
+	}
+	for c, msg := range defaults {
+		if EqualCode(c, code) {
+			return msg, true
+		}
+	}
+	return "", false
+}
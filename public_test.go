@@ -0,0 +1,97 @@
+package failure_test
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/morikuni/failure"
+)
+
+func TestPublicMessageOf(t *testing.T) {
+	failure.SetDefaultPublicMessage(TestCodeB, "default b")
+	defer failure.SetDefaultPublicMessage(TestCodeB, "")
+
+	tests := map[string]struct {
+		err     error
+		wantMsg string
+		wantOK  bool
+	}{
+		"public message": {
+			err:     failure.New(TestCodeA, failure.Message("internal"), failure.PublicMessage("public")),
+			wantMsg: "public",
+			wantOK:  true,
+		},
+		"outermost": {
+			err:     failure.Wrap(failure.New(TestCodeA, failure.PublicMessage("inner")), failure.PublicMessage("outer")),
+			wantMsg: "outer",
+			wantOK:  true,
+		},
+		"wrapped by wrap": {
+			err:     failure.Wrap(failure.New(TestCodeA, failure.PublicMessage("public")), failure.Message("internal")),
+			wantMsg: "public",
+			wantOK:  true,
+		},
+		"no public message": {
+			err:     failure.New(TestCodeA, failure.Message("internal")),
+			wantMsg: "",
+			wantOK:  false,
+		},
+		"default": {
+			err:     failure.New(TestCodeB, failure.Message("internal")),
+			wantMsg: "default b",
+			wantOK:  true,
+		},
+		"translated": {
+			err:     failure.Translate(failure.New(TestCodeA, failure.PublicMessage("for a")), TestCodeB),
+			wantMsg: "default b",
+			wantOK:  true,
+		},
+		"unexpected": {
+			err:     failure.MarkUnexpected(failure.New(TestCodeA, failure.PublicMessage("public"))),
+			wantMsg: "",
+			wantOK:  false,
+		},
+		"not failure": {
+			err:     io.EOF,
+			wantMsg: "",
+			wantOK:  false,
+		},
+		"nil": {
+			err:     nil,
+			wantMsg: "",
+			wantOK:  false,
+		},
+	}
+
+	for title, test := range tests {
+		t.Run(title, func(t *testing.T) {
+			msg, ok := failure.PublicMessageOf(test.err)
+			shouldEqual(t, msg, test.wantMsg)
+			shouldEqual(t, ok, test.wantOK)
+		})
+	}
+}
+
+func TestPublicMessage(t *testing.T) {
+	err := failure.New(TestCodeA, failure.PublicMessage("public"), failure.Message("internal"))
+
+	shouldEqual(t, err.Error(), "failure_test.TestPublicMessage: public: internal: code(code_a)")
+	msg, _ := failure.MessageOf(err)
+	shouldEqual(t, msg, "internal")
+	shouldContain(t, fmt.Sprintf("%+v", err), "    public_message(\"public\")\n    message(\"internal\")\n")
+
+	var st failure.StringTracer
+	failure.Trace(err, &st)
+	shouldContain(t, fmt.Sprint(st), "public_message = public message = internal")
+
+	b, e := failure.Marshal(err)
+	shouldEqual(t, e, nil)
+	shouldContain(t, string(b), `{"public_message":"public"}`)
+	var decoded error
+	shouldEqual(t, failure.Unmarshal(b, &decoded, nil), nil)
+	msg, _ = failure.PublicMessageOf(decoded)
+	shouldEqual(t, msg, "public")
+
+	shouldEqual(t, failure.Layers(err)[0].PublicMessage, "public")
+}
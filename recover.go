@@ -0,0 +1,93 @@
+package failure
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// Recover recovers a panic and sets it to the err.
+// It must be called directly by defer statement.
+//
+//	func Foo() (err error) {
+//		defer failure.Recover(&err)
+//		...
+//	}
+//
+// The call stack of the returned error is for the place where the panic
+// occurred. If the panic value is an error, it is wrapped and the code of
+// the error is kept. Otherwise, the error is an unexpected error.
+func Recover(err *error) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	*err = recovered(v)
+}
+
+// Go runs f in a new goroutine and sends the returned error to the returned
+// channel. A panic in f is recovered as an error like Recover.
+func Go(f func() error) <-chan error {
+	c := make(chan error, 1)
+	go func() {
+		var err error
+		defer func() {
+			c <- err
+		}()
+		defer Recover(&err)
+		err = f()
+	}()
+	return c
+}
+
+// recovered converts the panic value v to an error.
+// It must be called by the function which called recover.
+func recovered(v interface{}) error {
+	cs := panicCallers(2)
+
+	err, ok := v.(error)
+	if !ok {
+		return Custom(unexpected(fmt.Sprintf("panic: %v", v)), WithFormatter(), WithCallStack(cs))
+	}
+	if _, ok := CodeOf(err); !ok {
+		return Custom(Custom(err, WithUnexpected()), WithFormatter(), WithCallStack(cs))
+	}
+	return Custom(err, WithFormatter(), WithCallStack(cs))
+}
+
+// panicCallers returns the call stack from the function which called panic.
+// If it is not panicking, the call stack of the caller is returned.
+func panicCallers(skip int) CallStack {
+	pcs, _ := capturePCs(skip+1, UnlimitedCallStackDepth)
+
+	start := -1
+	for i, pc := range pcs {
+		name := funcName(pc)
+		switch {
+		case name == "runtime.gopanic":
+			start = i + 1
+		case start == i && strings.HasPrefix(name, "runtime."):
+			// Skip runtime frames such as runtime.sigpanic and
+			// runtime.panicmem to point where the user code panicked.
+			start = i + 1
+		}
+	}
+	if start != -1 && start < len(pcs) {
+		pcs = pcs[start:]
+	}
+
+	depth := int(atomic.LoadInt32(&callStackDepth))
+	if depth > 0 && len(pcs) > depth {
+		return callStack{pcs[:depth], true}
+	}
+	return callStack{pcs, false}
+}
+
+func funcName(pc uintptr) string {
+	f := runtime.FuncForPC(pc - 1)
+	if f == nil {
+		return ""
+	}
+	return f.Name()
+}
@@ -0,0 +1,91 @@
+package failure_test
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+
+	"github.com/morikuni/failure"
+)
+
+func panicWith(v interface{}) (err error) {
+	defer failure.Recover(&err)
+	if v == nil {
+		var m map[string]int
+		m["a"] = 1
+	}
+	panic(v)
+}
+
+func TestRecover(t *testing.T) {
+	tests := map[string]struct {
+		v         interface{}
+		wantCode  failure.Code
+		wantError string
+		wantLine  int
+	}{
+		"string": {
+			v:         "oops",
+			wantError: "failure_test.panicWith: panic: oops",
+			wantLine:  18,
+		},
+		"error": {
+			v:         io.EOF,
+			wantError: "failure_test.panicWith: unexpected: EOF",
+			wantLine:  18,
+		},
+		"code": {
+			v:         failure.New(TestCodeA),
+			wantCode:  TestCodeA,
+			wantError: "failure_test.panicWith: failure_test.TestRecover: code(code_a)",
+			wantLine:  18,
+		},
+		"runtime error": {
+			v:         nil,
+			wantError: "failure_test.panicWith: unexpected: assignment to entry in nil map",
+			wantLine:  16,
+		},
+	}
+
+	for title, test := range tests {
+		t.Run(title, func(t *testing.T) {
+			err := panicWith(test.v)
+
+			shouldEqual(t, err.Error(), test.wantError)
+			code, ok := failure.CodeOf(err)
+			shouldEqual(t, ok, test.wantCode != nil)
+			shouldEqual(t, code, test.wantCode)
+
+			var cs failure.CallStack
+			shouldEqual(t, errors.As(err, &cs), true)
+			shouldEqual(t, cs.HeadFrame().Func(), "panicWith")
+			shouldEqual(t, cs.HeadFrame().Line(), test.wantLine)
+
+			if e, ok := test.v.(error); ok {
+				shouldEqual(t, errors.Is(err, e), true)
+			}
+		})
+	}
+
+	var re runtime.Error
+	shouldEqual(t, errors.As(panicWith(nil), &re), true)
+	shouldEqual(t, panicWithNoPanic(), nil)
+}
+
+func panicWithNoPanic() (err error) {
+	defer failure.Recover(&err)
+	return nil
+}
+
+func TestGo(t *testing.T) {
+	err := <-failure.Go(func() error {
+		panic("oops")
+	})
+	shouldMatch(t, err.Error(), `failure_test.TestGo.func1: panic: oops`)
+
+	err = <-failure.Go(func() error {
+		return io.EOF
+	})
+	shouldEqual(t, err, io.EOF)
+}
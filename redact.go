@@ -0,0 +1,231 @@
+package failure
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// RedactedValue is the value rendered instead of sensitive values by
+// RedactMask.
+const RedactedValue = "[REDACTED]"
+
+// RedactionPolicy decides how sensitive values are rendered.
+// Sensitive values are values of keys set by SetSensitiveKeys, values
+// marked by Sensitive, values of SensitiveContext and SensitiveMessage.
+// Error(), %+v, Tracer, Marshal, log/slog and extraction as Context,
+// TypedContext and Messenger respect the policy, while Unredacted and
+// ValueOf show the values as they are.
+type RedactionPolicy interface {
+	// Redact returns the value rendered instead of the sensitive value of
+	// the key. The key is empty for messages.
+	Redact(key string, value interface{}) interface{}
+}
+
+// RedactionPolicyFunc is an adaptor to use function as the RedactionPolicy
+// interface.
+type RedactionPolicyFunc func(key string, value interface{}) interface{}
+
+// Redact implements the RedactionPolicy interface.
+func (f RedactionPolicyFunc) Redact(key string, value interface{}) interface{} {
+	return f(key, value)
+}
+
+var (
+	// RedactMask is a RedactionPolicy which replaces sensitive values with
+	// RedactedValue. This is the default policy.
+	RedactMask RedactionPolicy = RedactionPolicyFunc(func(string, interface{}) interface{} {
+		return RedactedValue
+	})
+	// RedactNone is a RedactionPolicy which shows sensitive values as they
+	// are. It is intended for local development.
+	RedactNone RedactionPolicy = RedactionPolicyFunc(func(_ string, value interface{}) interface{} {
+		return value
+	})
+)
+
+type redaction struct {
+	policy RedactionPolicy
+	keys   map[string]bool
+}
+
+var (
+	// redactions holds *redaction. It is replaced as a whole on update.
+	redactions   atomic.Value
+	redactionsMu sync.Mutex
+)
+
+func init() {
+	redactions.Store(&redaction{policy: RedactMask})
+}
+
+// SetRedactionPolicy sets the RedactionPolicy.
+// If the policy is nil, RedactMask is used.
+// Sensitive values are redacted when they are rendered, thus the policy
+// applies to errors created before the call as well.
+func SetRedactionPolicy(policy RedactionPolicy) {
+	if policy == nil {
+		policy = RedactMask
+	}
+	updateRedaction(func(r *redaction) {
+		r.policy = policy
+	})
+}
+
+// SetSensitiveKeys sets the keys of Context, OrderedContext and
+// TypedContext whose values are always sensitive (e.g. "password").
+// It replaces the keys set previously.
+// It should be called on initialization of the program.
+func SetSensitiveKeys(keys ...string) {
+	updateRedaction(func(r *redaction) {
+		r.keys = make(map[string]bool, len(keys))
+		for _, k := range keys {
+			r.keys[k] = true
+		}
+	})
+}
+
+func updateRedaction(f func(r *redaction)) {
+	redactionsMu.Lock()
+	defer redactionsMu.Unlock()
+
+	old := redactions.Load().(*redaction)
+	r := &redaction{policy: old.policy, keys: old.keys}
+	f(r)
+	redactions.Store(r)
+}
+
+func redact(key string, value interface{}) interface{} {
+	return redactions.Load().(*redaction).policy.Redact(key, value)
+}
+
+// Sensitive marks the value of TypedContext as sensitive.
+//
+//	failure.TypedContext{"email": failure.Sensitive(email)}
+func Sensitive(v interface{}) SensitiveValue {
+	return SensitiveValue{v}
+}
+
+// SensitiveValue is a value marked by Sensitive.
+// It is rendered by the RedactionPolicy even if it is printed directly.
+type SensitiveValue struct {
+	Value interface{}
+}
+
+// String implements the fmt.Stringer interface.
+func (v SensitiveValue) String() string {
+	return fmt.Sprint(redact("", v.Value))
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (v SensitiveValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(redact("", v.Value))
+}
+
+// SensitiveContext is a Context whose values are all sensitive.
+type SensitiveContext map[string]string
+
+// WrapError implements the Wrapper interface.
+func (c SensitiveContext) WrapError(err error) error {
+	return newWithContext(Context(c), nil, true, err)
+}
+
+// SensitiveMessage is a Message which is sensitive.
+type SensitiveMessage string
+
+// WrapError implements the Wrapper interface.
+func (m SensitiveMessage) WrapError(err error) error {
+	return &withMessage{Message(m), err, true}
+}
+
+// redactContext returns the context with values redacted by the r.
+// If all is true, all values are sensitive.
+// It returns false if no value is redacted.
+func redactContext(r *redaction, c Context, ordered OrderedContext, all bool) (Context, OrderedContext, bool) {
+	if !all {
+		sensitive := false
+		for k := range r.keys {
+			if _, ok := c[k]; ok {
+				sensitive = true
+				break
+			}
+		}
+		if !sensitive {
+			return c, ordered, false
+		}
+	}
+
+	rc := make(Context, len(c))
+	for k, v := range c {
+		if all || r.keys[k] {
+			rc[k] = fmt.Sprint(r.policy.Redact(k, v))
+		} else {
+			rc[k] = v
+		}
+	}
+
+	var ro OrderedContext
+	if ordered != nil {
+		ro = make(OrderedContext, len(ordered))
+		for i, kv := range ordered {
+			ro[i] = KV{kv.Key, rc[kv.Key]}
+		}
+	}
+	return rc, ro, true
+}
+
+// redact returns the context with values redacted by the r.
+// If reveal is true, values marked by Sensitive are unmarked instead.
+func (c TypedContext) redact(r *redaction, reveal bool) TypedContext {
+	rc := make(TypedContext, len(c))
+	for k, v := range c {
+		sv, marked := v.(SensitiveValue)
+		switch {
+		case reveal && marked:
+			rc[k] = sv.Value
+		case reveal:
+			rc[k] = v
+		case marked:
+			rc[k] = r.policy.Redact(k, sv.Value)
+		case r.keys[k]:
+			rc[k] = r.policy.Redact(k, v)
+		default:
+			rc[k] = v
+		}
+	}
+	return rc
+}
+
+// unredactedOf returns the view of the err which shows sensitive values.
+func unredactedOf(err error) error {
+	if r, ok := err.(interface{ unredacted() error }); ok {
+		return r.unredacted()
+	}
+	return err
+}
+
+// Unredacted returns a fmt.Formatter for internal debug output.
+// %+v prints the err like WithFormatter, but shows sensitive values as
+// they are. Other verbs print err.Error(), which is redacted.
+func Unredacted(err error) fmt.Formatter {
+	return unredactedFormatter{err}
+}
+
+type unredactedFormatter struct {
+	err error
+}
+
+func (f unredactedFormatter) Format(s fmt.State, verb rune) {
+	if f.err == nil {
+		io.WriteString(s, "<nil>")
+		return
+	}
+	if verb != 'v' || !s.Flag('+') {
+		io.WriteString(s, f.err.Error())
+		return
+	}
+	formatChain(s, f.err, true)
+	formatCallStacks(s, f.err, false)
+}
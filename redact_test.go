@@ -0,0 +1,113 @@
+package failure_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/morikuni/failure"
+)
+
+func newSensitiveError() error {
+	return failure.New(TestCodeA,
+		failure.SensitiveMessage("secret-message"),
+		failure.Context{"password": "secret-password", "user": "gopher"},
+		failure.SensitiveContext{"email": "secret-email"},
+		failure.TypedContext{"token": failure.Sensitive("secret-token"), "n": 1},
+	)
+}
+
+func shouldNotLeak(t *testing.T, s string) {
+	t.Helper()
+	if strings.Contains(s, "secret-") {
+		t.Errorf("%q contains a sensitive value", s)
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	failure.SetSensitiveKeys("password")
+	defer failure.SetSensitiveKeys()
+
+	err := newSensitiveError()
+
+	shouldEqual(t, err.Error(), "failure_test.newSensitiveError: [REDACTED]: password=[REDACTED] user=gopher: email=[REDACTED]: n=1 token=[REDACTED]: code(code_a)")
+	shouldNotLeak(t, fmt.Sprintf("%v", err))
+	shouldNotLeak(t, fmt.Sprintf("%+v", err))
+
+	var st failure.StringTracer
+	failure.Trace(err, &st)
+	shouldNotLeak(t, fmt.Sprint(st))
+
+	b, e := failure.Marshal(err)
+	shouldEqual(t, e, nil)
+	shouldNotLeak(t, string(b))
+
+	msg, ok := failure.MessageOf(err)
+	shouldEqual(t, ok, true)
+	shouldEqual(t, msg, failure.RedactedValue)
+
+	for _, l := range failure.Layers(err) {
+		shouldNotLeak(t, fmt.Sprint(l.Messages, l.Context))
+	}
+
+	v, ok := failure.ValueOf(err, "password")
+	shouldEqual(t, ok, true)
+	shouldEqual(t, v, "secret-password")
+	v, ok = failure.ValueOf(err, "token")
+	shouldEqual(t, ok, true)
+	shouldEqual(t, v, "secret-token")
+
+	debug := fmt.Sprintf("%+v", failure.Unredacted(err))
+	for _, s := range []string{`message("secret-message")`, "password = secret-password", "email = secret-email", "token = secret-token"} {
+		shouldContain(t, debug, s)
+	}
+	shouldEqual(t, fmt.Sprintf("%v", failure.Unredacted(err)), err.Error())
+	shouldEqual(t, fmt.Sprintf("%+v", failure.Unredacted(nil)), "<nil>")
+}
+
+func TestSetRedactionPolicy(t *testing.T) {
+	failure.SetRedactionPolicy(failure.RedactNone)
+	defer failure.SetRedactionPolicy(nil)
+
+	err := newSensitiveError()
+	shouldEqual(t, err.Error(), "failure_test.newSensitiveError: secret-message: password=secret-password user=gopher: email=secret-email: n=1 token=secret-token: code(code_a)")
+
+	failure.SetRedactionPolicy(failure.RedactionPolicyFunc(func(key string, v interface{}) interface{} {
+		return fmt.Sprintf("<%s:%d>", key, len(fmt.Sprint(v)))
+	}))
+	err = newSensitiveError()
+	shouldEqual(t, err.Error(), "failure_test.newSensitiveError: <:14>: password=secret-password user=gopher: email=<email:12>: n=1 token=<token:12>: code(code_a)")
+	shouldEqual(t, fmt.Sprint(failure.Sensitive("secret")), "<:6>")
+}
+
+func TestRedaction_RenderTime(t *testing.T) {
+	err := failure.New(TestCodeA,
+		failure.SensitiveMessage("secret-message"),
+		failure.Context{"password": "secret-password"},
+		failure.OrderedContext{{Key: "password", Value: "secret-password"}},
+		failure.TypedContext{"password": "secret-password"},
+	)
+	shouldEqual(t, strings.Count(err.Error(), "secret-password"), 3)
+
+	// Settings changed after wrapping apply to Context and TypedContext
+	// alike.
+	failure.SetSensitiveKeys("password")
+	defer failure.SetSensitiveKeys()
+
+	shouldEqual(t, err.Error(), "failure_test.TestRedaction_RenderTime: [REDACTED]: password=[REDACTED]: password=[REDACTED]: password=[REDACTED]: code(code_a)")
+	shouldNotLeak(t, fmt.Sprintf("%+v", err))
+	b, e := failure.Marshal(err)
+	shouldEqual(t, e, nil)
+	shouldNotLeak(t, string(b))
+	var tc failure.TypedContext
+	for i := failure.NewIterator(err); i.Next(); {
+		if i.As(&tc) {
+			break
+		}
+	}
+	shouldEqual(t, tc, failure.TypedContext{"password": failure.RedactedValue})
+
+	failure.SetRedactionPolicy(failure.RedactNone)
+	defer failure.SetRedactionPolicy(nil)
+	shouldEqual(t, strings.Count(err.Error(), "secret-"), 4)
+}
@@ -0,0 +1,133 @@
+package failure
+
+import (
+	"fmt"
+	"sync"
+)
+
+var _ CodeResolver = (*CodeRegistry)(nil)
+
+// DefaultCodeRegistry is the CodeRegistry used by RegisterCode, LookupCode
+// and RegisteredCodes.
+// Unmarshal also uses it when the resolver is nil.
+var DefaultCodeRegistry = NewCodeRegistry()
+
+// RegisterCode registers codes to the DefaultCodeRegistry.
+// It is intended to be called in init function of the package which
+// defines the codes, and panics if a code of the same string is already
+// registered from another package or as another value.
+func RegisterCode(codes ...Code) {
+	if err := DefaultCodeRegistry.register(callerPkg(1), codes); err != nil {
+		panic(err)
+	}
+}
+
+// LookupCode finds a code registered to the DefaultCodeRegistry by its
+// string representation.
+func LookupCode(s string) (Code, bool) {
+	return DefaultCodeRegistry.Lookup(s)
+}
+
+// RegisteredCodes returns codes registered to the DefaultCodeRegistry
+// in the registered order.
+func RegisteredCodes() []Code {
+	return DefaultCodeRegistry.Codes()
+}
+
+// CodeRegistry holds codes to reconstruct them from their string
+// representation (e.g. an error code received from another service).
+// It is safe for concurrent use.
+type CodeRegistry struct {
+	mu    sync.RWMutex
+	codes map[string]registeredCode
+	order []string
+}
+
+type registeredCode struct {
+	code Code
+	pkg  string
+}
+
+// NewCodeRegistry creates an empty CodeRegistry.
+func NewCodeRegistry() *CodeRegistry {
+	return &CodeRegistry{
+		codes: make(map[string]registeredCode),
+	}
+}
+
+// Register registers codes to the registry.
+// Registering the same code from the same package again is allowed, but
+// it returns an error if a code of the same string is already registered
+// from another package or as another value. In that case, none of the
+// codes are registered.
+func (r *CodeRegistry) Register(codes ...Code) error {
+	return r.register(callerPkg(1), codes)
+}
+
+func (r *CodeRegistry) register(pkg string, codes []Code) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	added := make(map[string]registeredCode, len(codes))
+	for _, c := range codes {
+		s, err := ErrorCodeOf(c)
+		if err != nil {
+			return fmt.Errorf("%v registered from %s", err, pkg)
+		}
+		rc, ok := r.codes[s]
+		if !ok {
+			rc, ok = added[s]
+		}
+		if ok {
+			if rc.pkg != pkg || !EqualCode(rc.code, c) {
+				return fmt.Errorf("failure: code %q (%T) registered from %s is already registered from %s as %T", s, c, pkg, rc.pkg, rc.code)
+			}
+			continue
+		}
+		added[s] = registeredCode{c, pkg}
+	}
+
+	for _, c := range codes {
+		s, _ := ErrorCodeOf(c)
+		if rc, ok := added[s]; ok {
+			r.codes[s] = rc
+			r.order = append(r.order, s)
+			delete(added, s)
+		}
+	}
+	return nil
+}
+
+// Lookup finds a code by its string representation.
+func (r *CodeRegistry) Lookup(s string) (Code, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rc, ok := r.codes[s]
+	return rc.code, ok
+}
+
+// ResolveCode implements the CodeResolver interface.
+func (r *CodeRegistry) ResolveCode(s string) (Code, bool) {
+	return r.Lookup(s)
+}
+
+// Codes returns the registered codes in the registered order.
+func (r *CodeRegistry) Codes() []Code {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	codes := make([]Code, len(r.order))
+	for i, s := range r.order {
+		codes[i] = r.codes[s].code
+	}
+	return codes
+}
+
+func callerPkg(skip int) string {
+	cs := Callers(skip + 1)
+	if cs == nil {
+		return ""
+	}
+	return cs.HeadFrame().PkgPath()
+}
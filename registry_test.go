@@ -0,0 +1,64 @@
+package failure_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/morikuni/failure"
+)
+
+func TestCodeRegistry(t *testing.T) {
+	const (
+		A failure.StringCode = "A"
+		B CustomCode         = "B"
+		C failure.StringCode = "B"
+	)
+
+	r := failure.NewCodeRegistry()
+	shouldEqual(t, r.Register(A, B), nil)
+	shouldEqual(t, r.Register(A), nil)
+
+	c, ok := r.Lookup("A")
+	shouldEqual(t, ok, true)
+	shouldEqual(t, c, A)
+	c, ok = r.Lookup("B")
+	shouldEqual(t, ok, true)
+	shouldEqual(t, c, B)
+	c, ok = r.ResolveCode("B")
+	shouldEqual(t, ok, true)
+	shouldEqual(t, c, B)
+	c, ok = r.Lookup("C")
+	shouldEqual(t, ok, false)
+	shouldEqual(t, c, nil)
+
+	shouldDiffer(t, r.Register(C), nil)
+	shouldDiffer(t, r.Register(failure.StringCode("D"), CustomCode("D")), nil)
+	shouldDiffer(t, r.Register(nil), nil)
+	shouldDiffer(t, r.Register((*pointerCode)(nil)), nil)
+	shouldEqual(t, r.Register(sliceCode{"E"}, sliceCode{"E"}), nil)
+	_, ok = r.Lookup("D")
+	shouldEqual(t, ok, false)
+
+	shouldEqual(t, r.Codes(), []failure.Code{A, B, sliceCode{"E"}})
+}
+
+func TestRegisterCode(t *testing.T) {
+	const A CustomCode = "registry_test_a"
+
+	failure.RegisterCode(A)
+	failure.RegisterCode(A)
+
+	c, ok := failure.LookupCode("registry_test_a")
+	shouldEqual(t, ok, true)
+	shouldEqual(t, c, A)
+	shouldContain(t, fmt.Sprint(failure.RegisteredCodes()), "registry_test_a")
+
+	var err error
+	shouldEqual(t, failure.Unmarshal([]byte(`{"chain":[{"code":"registry_test_a"}]}`), &err, nil), nil)
+	shouldEqual(t, failure.Is(err, A), true)
+
+	defer func() {
+		shouldDiffer(t, recover(), nil)
+	}()
+	failure.RegisterCode(failure.StringCode("registry_test_a"))
+}
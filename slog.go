@@ -0,0 +1,244 @@
+package failure
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+var _ = []slog.LogValuer{
+	(*withMessage)(nil),
+	(*withPublicMessage)(nil),
+	(*withMessageID)(nil),
+	(*withContext)(nil),
+	(*withTypedContext)(nil),
+	(*withCallStack)(nil),
+	(*formatter)(nil),
+	(*withCode)(nil),
+	(*withUnexpected)(nil),
+}
+
+// LogValueOf returns a group value of the err for log/slog.
+// The group has following attributes, and empty ones are omitted.
+//
+//	error:          err.Error()
+//	code:           the code from CodeOf
+//	messages:       all messages in the chain
+//	public_message: the message from PublicMessageOf
+//	context:        a group of all contexts in the chain
+//	                (values of TypedContext keep their types)
+//	frame:          the head frame of the call stack from CallStackOf
+//	unexpected:     true if the code is hidden by an unexpected error
+//
+// Errors created by this package implement slog.LogValuer with this function.
+func LogValueOf(err error) slog.Value {
+	if err == nil {
+		return slog.GroupValue()
+	}
+
+	attrs := []slog.Attr{slog.String("error", err.Error())}
+
+	code, hasCode := CodeOf(err)
+	if hasCode {
+		attrs = append(attrs, slog.String("code", codeString(code)))
+	}
+
+	var (
+		msgs       []string
+		ctx        []slog.Attr
+		seen       = map[string]bool{}
+		unexpected bool
+	)
+	i := NewIterator(err)
+	for i.Next() {
+		var (
+			msg Messenger
+			tc  TypedContext
+			oc  OrderedContext
+		)
+		switch {
+		case i.As(&msg):
+			msgs = append(msgs, msg.Message())
+		case i.As(&tc):
+			for _, kv := range tc.sorted() {
+				// The outer context takes precedence.
+				if !seen[kv.Key] {
+					seen[kv.Key] = true
+					ctx = append(ctx, slog.Any(kv.Key, tc[kv.Key]))
+				}
+			}
+		case i.As(&oc):
+			for _, kv := range oc {
+				if !seen[kv.Key] {
+					seen[kv.Key] = true
+					ctx = append(ctx, slog.String(kv.Key, kv.Value))
+				}
+			}
+		}
+		if isUnexpected(i.Error()) {
+			unexpected = true
+		}
+	}
+
+	if len(msgs) != 0 {
+		attrs = append(attrs, slog.Any("messages", msgs))
+	}
+	if pub, ok := PublicMessageOf(err); ok {
+		attrs = append(attrs, slog.String("public_message", pub))
+	}
+	if len(ctx) != 0 {
+		attrs = append(attrs, slog.Attr{Key: "context", Value: slog.GroupValue(ctx...)})
+	}
+	if cs, ok := CallStackOf(err); ok {
+		attrs = append(attrs, slog.String("frame", fmt.Sprintf("%+v", cs.HeadFrame())))
+	}
+	if unexpected && !hasCode {
+		attrs = append(attrs, slog.Bool("unexpected", true))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+func contextLogValue(oc OrderedContext) slog.Value {
+	attrs := make([]slog.Attr, len(oc))
+	for i, kv := range oc {
+		attrs[i] = slog.String(kv.Key, kv.Value)
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func (w *withMessage) LogValue() slog.Value {
+	return LogValueOf(w)
+}
+
+func (w *withPublicMessage) LogValue() slog.Value {
+	return LogValueOf(w)
+}
+
+func (w *withMessageID) LogValue() slog.Value {
+	return LogValueOf(w)
+}
+
+func (w *withContext) LogValue() slog.Value {
+	return LogValueOf(w)
+}
+
+func (w *withTypedContext) LogValue() slog.Value {
+	return LogValueOf(w)
+}
+
+func (w *withCallStack) LogValue() slog.Value {
+	return LogValueOf(w)
+}
+
+func (f *formatter) LogValue() slog.Value {
+	return LogValueOf(f)
+}
+
+func (w *withCode) LogValue() slog.Value {
+	return LogValueOf(w)
+}
+
+func (w *withUnexpected) LogValue() slog.Value {
+	return LogValueOf(w)
+}
+
+var _ Tracer = (*SlogTracer)(nil)
+
+// SlogTracer is a Tracer which collects slog.Attr in the order of the chain.
+type SlogTracer []slog.Attr
+
+// Push implements the Tracer interface.
+func (st *SlogTracer) Push(v interface{}) {
+	switch t := v.(type) {
+	case Code:
+		*st = append(*st, slog.String("code", codeString(t)))
+		if _, ok := t.(PayloadCode); ok {
+			*st = append(*st, slog.Any("payload", t))
+		}
+		return
+	case Message:
+		*st = append(*st, slog.String("message", t.String()))
+		return
+	case PublicMessage:
+		*st = append(*st, slog.String("public_message", t.String()))
+		return
+	case MessageID:
+		*st = append(*st, slog.String("message_id", string(t)))
+		return
+	case CallStack:
+		*st = append(*st, slog.String("frame", fmt.Sprintf("%+v", t.HeadFrame())))
+		return
+	case Context:
+		*st = append(*st, slog.Attr{Key: "context", Value: contextLogValue(t.sorted())})
+		return
+	case OrderedContext:
+		*st = append(*st, slog.Attr{Key: "context", Value: contextLogValue(t)})
+		return
+	case TypedContext:
+		attrs := make([]slog.Attr, 0, len(t))
+		for _, kv := range t.sorted() {
+			attrs = append(attrs, slog.Any(kv.Key, t[kv.Key]))
+		}
+		*st = append(*st, slog.Attr{Key: "context", Value: slog.GroupValue(attrs...)})
+		return
+	case error:
+		if isUnexpected(t) {
+			*st = append(*st, slog.String("unexpected", fmt.Sprint(t)))
+			return
+		}
+	}
+	*st = append(*st, slog.Any(fmt.Sprintf("%T", v), v))
+}
+
+// NewSlogHandler wraps h to expand all errors in attributes with
+// LogValueOf, including errors not created by this package.
+func NewSlogHandler(h slog.Handler) slog.Handler {
+	return &slogHandler{h}
+}
+
+type slogHandler struct {
+	handler slog.Handler
+}
+
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		nr.AddAttrs(expandErrorAttr(a))
+		return true
+	})
+	return h.handler.Handle(ctx, nr)
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	expanded := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		expanded[i] = expandErrorAttr(a)
+	}
+	return &slogHandler{h.handler.WithAttrs(expanded)}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	return &slogHandler{h.handler.WithGroup(name)}
+}
+
+func expandErrorAttr(a slog.Attr) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.Attr{Key: a.Key, Value: LogValueOf(err)}
+		}
+	case slog.KindGroup:
+		group := a.Value.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			attrs[i] = expandErrorAttr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}
+	}
+	return a
+}
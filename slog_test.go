@@ -0,0 +1,77 @@
+package failure_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/morikuni/failure"
+)
+
+func newTestLogger(buf *bytes.Buffer, wrap bool) *slog.Logger {
+	var h slog.Handler = slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	if wrap {
+		h = failure.NewSlogHandler(h)
+	}
+	return slog.New(h)
+}
+
+func TestLogValueOf(t *testing.T) {
+	err := failure.Translate(
+		failure.New(TestCodeA, failure.Message("xxx"), failure.Context{"b": "2", "a": "1"}),
+		TestCodeB,
+		failure.Message("yyy"),
+		failure.Context{"a": "0"},
+	)
+
+	buf := &bytes.Buffer{}
+	newTestLogger(buf, false).Info("hello", "err", err)
+	shouldMatch(t, buf.String(), `^level=INFO msg=hello err.error="failure_test.TestLogValueOf: yyy: a=0: code\(1\): failure_test.TestLogValueOf: xxx: a=1 b=2: code\(code_a\)" err.code=1 err.messages="\[yyy xxx\]" err.context.a=0 err.context.b=2 err.frame="\[failure_test.TestLogValueOf\] /.+/failure/slog_test.go:30"\n$`)
+
+	buf.Reset()
+	newTestLogger(buf, false).Info("hello", "err", failure.MarkUnexpected(failure.New(TestCodeA)))
+	shouldMatch(t, buf.String(), `err.frame="\[failure_test.TestLogValueOf\] /.+/failure/slog_test.go:41" err.unexpected=true\n$`)
+
+	shouldEqual(t, failure.LogValueOf(nil), slog.GroupValue())
+}
+
+func TestSlogHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := newTestLogger(buf, true).With("base", fmt.Errorf("base: %w", io.EOF))
+	logger.Info("hello", slog.Group("g", "err", fmt.Errorf("wrap: %w", failure.New(TestCodeA))))
+	shouldMatch(t, buf.String(), `^level=INFO msg=hello base.error="base: EOF" g.err.error="wrap: failure_test.TestSlogHandler: code\(code_a\)" g.err.code=code_a g.err.frame=".+"\n$`)
+}
+
+func TestSlogTracer(t *testing.T) {
+	err := failure.MarkUnexpected(failure.New(TestCodeA, failure.Message("xxx"), failure.Context{"b": "2", "a": "1"}))
+
+	var st failure.SlogTracer
+	failure.Trace(err, &st)
+
+	buf := &bytes.Buffer{}
+	newTestLogger(buf, false).Info("hello", "trace", slog.GroupValue(st...))
+	shouldMatch(t, buf.String(), `^level=INFO msg=hello trace.frame="\[failure_test.TestSlogTracer\] .+" trace.unexpected="mark unexpected" trace.frame="\[failure_test.TestSlogTracer\] .+" trace.message=xxx trace.context.a=1 trace.context.b=2 trace.code=code_a\n$`)
+}
+
+func TestLogValueOf_TypedContext(t *testing.T) {
+	err := failure.New(TestCodeA, failure.TypedContext{"n": 1, "ok": true}, failure.Context{"n": "2", "s": "x"})
+
+	buf := &bytes.Buffer{}
+	slog.New(slog.NewJSONHandler(buf, nil)).Info("hello", "err", err)
+	shouldContain(t, buf.String(), `"context":{"n":1,"ok":true,"s":"x"}`)
+
+	var st failure.SlogTracer
+	failure.Trace(err, &st)
+	buf.Reset()
+	slog.New(slog.NewJSONHandler(buf, nil)).Info("hello", "trace", slog.GroupValue(st...))
+	shouldContain(t, buf.String(), `"context":{"n":1,"ok":true}`)
+}
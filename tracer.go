@@ -22,22 +22,38 @@ type StringTracer []string
 func (st *StringTracer) Push(v interface{}) {
 	switch t := v.(type) {
 	case Code:
-		*st = append(*st, fmt.Sprintf("code = %s", t.ErrorCode()))
+		*st = append(*st, fmt.Sprintf("code = %s", codeDetail(t)))
 		return
 	case Message:
 		*st = append(*st, fmt.Sprintf("message = %s", t))
 		return
+	case PublicMessage:
+		*st = append(*st, fmt.Sprintf("public_message = %s", t))
+		return
+	case MessageID:
+		*st = append(*st, fmt.Sprintf("message_id = %s", t))
+		return
 	case CallStack:
 		head := t.HeadFrame()
 		*st = append(*st, fmt.Sprintf("[%s] %s:%d", head.Func(), head.Path(), head.Line()))
 		return
 	case Context:
-		for k, v := range t {
-			*st = append(*st, fmt.Sprintf("%s = %s", k, v))
+		for _, kv := range t.sorted() {
+			*st = append(*st, fmt.Sprintf("%s = %s", kv.Key, kv.Value))
+		}
+		return
+	case TypedContext:
+		for _, kv := range t.sorted() {
+			*st = append(*st, fmt.Sprintf("%s = %s", kv.Key, kv.Value))
+		}
+		return
+	case OrderedContext:
+		for _, kv := range t {
+			*st = append(*st, fmt.Sprintf("%s = %s", kv.Key, kv.Value))
 		}
 		return
-	case interface{ Unexpected() bool }:
-		if t.Unexpected() {
+	case error:
+		if isUnexpected(t) {
 			*st = append(*st, fmt.Sprintf("unexpected: %v", t))
 			return
 		}
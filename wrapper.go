@@ -6,11 +6,15 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"sync/atomic"
 )
 
 var _ = []interface{ Unwrap() error }{
 	(*withMessage)(nil),
+	(*withPublicMessage)(nil),
+	(*withMessageID)(nil),
 	(*withContext)(nil),
+	(*withTypedContext)(nil),
 	(*withCallStack)(nil),
 	(*formatter)(nil),
 	(*withCode)(nil),
@@ -27,7 +31,13 @@ type Wrapper interface {
 var _ = []Wrapper{
 	WrapperFunc(nil),
 	Context{},
+	OrderedContext{},
+	TypedContext{},
+	SensitiveContext{},
 	Message(""),
+	SensitiveMessage(""),
+	PublicMessage(""),
+	MessageID(""),
 }
 
 // WrapperFunc is an adaptor to use function as the Wrapper interface.
@@ -53,7 +63,7 @@ func (m Message) String() string {
 
 // WrapError implements Wrapper interface.
 func (m Message) WrapError(err error) error {
-	return &withMessage{m, err}
+	return &withMessage{m, err, false}
 }
 
 func (m Message) Message() string {
@@ -68,31 +78,48 @@ func Messagef(format string, args ...interface{}) Message {
 type withMessage struct {
 	message    Message
 	underlying error
+	// sensitive is true for SensitiveMessage.
+	sensitive bool
+}
+
+// text returns the message redacted by the current RedactionPolicy.
+func (w *withMessage) text() Message {
+	if w.sensitive {
+		return Message(fmt.Sprint(redact("", string(w.message))))
+	}
+	return w.message
 }
 
 func (w *withMessage) Error() string {
-	return fmt.Sprintf("%s: %s", w.message, w.underlying)
+	return fmt.Sprintf("%s: %s", w.text(), w.underlying)
 }
 
 func (w *withMessage) Unwrap() error {
 	return w.underlying
 }
 
+func (w *withMessage) unredacted() error {
+	if w.sensitive {
+		return &withMessage{w.message, w.underlying, false}
+	}
+	return w
+}
+
 // Deprecated: This function will be deleted in v1.0.0 release. Please use As method on Iterator.
 func (w *withMessage) GetMessage() string {
-	return w.message.String()
+	return w.text().String()
 }
 
 func (w *withMessage) As(x interface{}) bool {
 	switch t := x.(type) {
 	case *Message:
-		*t = w.message
+		*t = w.text()
 		return true
 	case *Messenger:
-		*t = w.message
+		*t = w.text()
 		return true
 	case *Tracer:
-		(*t).Push(w.message)
+		(*t).Push(w.text())
 		return true
 	}
 	return false
@@ -119,9 +146,8 @@ type Contexter interface {
 // Context is a key-value data which describes the how the error occurred
 // for debugging purpose. You must not use context data as a part of your
 // application logic. Just print it.
-// If you want to extract Context from error for printing purpose, you can
-// define an interface with method `GetContext() Context` and use it with
-// iterator, like other extraction functions (see: MessageOf).
+// If you want to extract Context from error for printing purpose, use
+// First or FindAll (e.g. FindAll[Context](err)).
 type Context map[string]string
 
 func (c Context) Context() Context {
@@ -130,57 +156,322 @@ func (c Context) Context() Context {
 
 // WrapError implements the Wrapper interface.
 func (c Context) WrapError(err error) error {
+	return newWithContext(c, nil, false, err)
+}
+
+// sorted returns the context as OrderedContext sorted by key.
+func (c Context) sorted() OrderedContext {
 	keys := make([]string, 0, len(c))
 	for k := range c {
 		keys = append(keys, k)
 	}
-	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	sort.Strings(keys)
+
+	oc := make(OrderedContext, len(keys))
+	for i, k := range keys {
+		oc[i] = KV{k, c[k]}
+	}
+	return oc
+}
+
+// KV is a key-value pair of OrderedContext.
+type KV struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// OrderedContext is a Context which keeps the order of key-values
+// for printing. Context is printed in the order of keys.
+//
+//	failure.OrderedContext{{"user_id", userID}, {"item_id", itemID}}
+//
+// The error wrapped by OrderedContext can also be extracted as Context.
+// If a key appears more than once, the last value is used.
+type OrderedContext []KV
+
+// Context returns the key-values as Context.
+func (oc OrderedContext) Context() Context {
+	c := make(Context, len(oc))
+	for _, kv := range oc {
+		c[kv.Key] = kv.Value
+	}
+	return c
+}
 
+// WrapError implements the Wrapper interface.
+func (oc OrderedContext) WrapError(err error) error {
+	c := oc.Context()
+	unique := make(OrderedContext, 0, len(c))
+	seen := make(map[string]bool, len(c))
+	for _, kv := range oc {
+		if !seen[kv.Key] {
+			seen[kv.Key] = true
+			unique = append(unique, KV{kv.Key, c[kv.Key]})
+		}
+	}
+	return newWithContext(c, unique, false, err)
+}
+
+func (oc OrderedContext) memo() string {
 	buf := &bytes.Buffer{}
-	for _, k := range keys {
-		v := c[k]
+	for _, kv := range oc {
 		if buf.Len() != 0 {
 			buf.WriteRune(' ')
 		}
-		fmt.Fprintf(buf, "%s=%s", k, v)
+		fmt.Fprintf(buf, "%s=%s", kv.Key, kv.Value)
 	}
-	return &withContext{c, buf.String(), err}
+	return buf.String()
 }
 
 type withContext struct {
-	ctx        Context
-	memo       string
+	ctx     Context
+	ordered OrderedContext
+	// all is true if all values are sensitive.
+	all bool
+	// reveal is true for the unredacted view.
+	reveal     bool
 	underlying error
+	// view holds *contextView rendered by the current redaction settings.
+	view atomic.Value
+}
+
+// contextView is the context redacted by the redaction settings r.
+type contextView struct {
+	r       *redaction
+	ctx     Context
+	ordered OrderedContext
+	memo    string
+}
+
+// newWithContext wraps the err with the context.
+// Sensitive values are redacted when they are rendered.
+// If all is true, all values are sensitive.
+func newWithContext(c Context, ordered OrderedContext, all bool, err error) *withContext {
+	return &withContext{ctx: c, ordered: ordered, all: all, underlying: err}
 }
 
 func (w *withContext) Error() string {
-	return fmt.Sprintf("%s: %s", w.memo, w.underlying)
+	return fmt.Sprintf("%s: %s", w.render().memo, w.underlying)
 }
 
 func (w *withContext) Unwrap() error {
 	return w.underlying
 }
 
+func (w *withContext) unredacted() error {
+	return &withContext{ctx: w.ctx, ordered: w.ordered, all: w.all, reveal: true, underlying: w.underlying}
+}
+
 // Deprecated: This function will be deleted in v1.0.0 release. Please use As method on Iterator.
 func (w *withContext) GetContext() Context {
-	return w.ctx
+	return w.render().ctx
 }
 
 func (w *withContext) As(x interface{}) bool {
 	switch t := x.(type) {
 	case *Contexter:
-		*t = w.ctx
+		*t = w.render().ctx
+		return true
+	case *Context:
+		*t = w.render().ctx
+		return true
+	case *OrderedContext:
+		*t = w.render().pairs()
+		return true
+	case *Tracer:
+		v := w.render()
+		if v.ordered != nil {
+			(*t).Push(v.ordered)
+		} else {
+			(*t).Push(v.ctx)
+		}
+		return true
+	}
+	return false
+}
+
+// render returns the context redacted by the current redaction settings.
+// The result is cached until the settings are changed.
+func (w *withContext) render() *contextView {
+	r := redactions.Load().(*redaction)
+	if v, ok := w.view.Load().(*contextView); ok && v.r == r {
+		return v
+	}
+
+	v := &contextView{r: r, ctx: w.ctx, ordered: w.ordered}
+	if !w.reveal {
+		if rc, ro, ok := redactContext(r, w.ctx, w.ordered, w.all); ok {
+			v.ctx, v.ordered = rc, ro
+		}
+	}
+	v.memo = v.pairs().memo()
+	w.view.Store(v)
+	return v
+}
+
+// pairs returns the context in the order for printing.
+func (v *contextView) pairs() OrderedContext {
+	if v.ordered != nil {
+		return v.ordered
+	}
+	return v.ctx.sorted()
+}
+
+// TypedContext is a Context which holds values of any type.
+// The values are converted to strings with fmt.Sprint only when they are
+// printed, and Tracer, Marshal and log/slog receive the values as they are.
+//
+//	failure.TypedContext{"user_id": userID, "elapsed": time.Since(start)}
+//
+// The error wrapped by TypedContext can also be extracted as Context and
+// OrderedContext in the order of keys.
+type TypedContext map[string]interface{}
+
+// WrapError implements the Wrapper interface.
+func (c TypedContext) WrapError(err error) error {
+	return &withTypedContext{ctx: c, underlying: err}
+}
+
+// Context returns the key-values as Context.
+func (c TypedContext) Context() Context {
+	return c.sorted().Context()
+}
+
+// sorted returns the string representation of the context sorted by key.
+func (c TypedContext) sorted() OrderedContext {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	oc := make(OrderedContext, len(keys))
+	for i, k := range keys {
+		oc[i] = KV{k, fmt.Sprint(c[k])}
+	}
+	return oc
+}
+
+type withTypedContext struct {
+	ctx TypedContext
+	// reveal is true for the unredacted view.
+	reveal     bool
+	underlying error
+	// view holds *typedContextView rendered by the current redaction
+	// settings.
+	view atomic.Value
+}
+
+// typedContextView is the context redacted by the redaction settings r.
+type typedContextView struct {
+	r        *redaction
+	redacted TypedContext
+	strs     OrderedContext
+}
+
+func (w *withTypedContext) Error() string {
+	return fmt.Sprintf("%s: %s", w.pairs().memo(), w.underlying)
+}
+
+func (w *withTypedContext) Unwrap() error {
+	return w.underlying
+}
+
+func (w *withTypedContext) unredacted() error {
+	return &withTypedContext{ctx: w.ctx, reveal: true, underlying: w.underlying}
+}
+
+func (w *withTypedContext) As(x interface{}) bool {
+	switch t := x.(type) {
+	case *TypedContext:
+		*t = w.values()
+		return true
+	case *Contexter:
+		*t = w.pairs().Context()
 		return true
 	case *Context:
-		*t = w.ctx
+		*t = w.pairs().Context()
+		return true
+	case *OrderedContext:
+		*t = w.pairs()
 		return true
 	case *Tracer:
-		(*t).Push(w.ctx)
+		(*t).Push(w.values())
 		return true
 	}
 	return false
 }
 
+// values returns the redacted context.
+func (w *withTypedContext) values() TypedContext {
+	return w.render().redacted
+}
+
+// pairs returns the redacted context converted to strings in the order
+// for printing.
+func (w *withTypedContext) pairs() OrderedContext {
+	return w.render().strs
+}
+
+// render returns the context redacted by the current redaction settings.
+// The result is cached until the settings are changed.
+func (w *withTypedContext) render() *typedContextView {
+	r := redactions.Load().(*redaction)
+	if v, ok := w.view.Load().(*typedContextView); ok && v.r == r {
+		return v
+	}
+
+	redacted := w.ctx.redact(r, w.reveal)
+	v := &typedContextView{r, redacted, redacted.sorted()}
+	w.view.Store(v)
+	return v
+}
+
+// ValueOf extracts a value of the key in TypedContext or Context from
+// the err. If the key appears more than once, the outermost one is
+// returned. Values in Context are returned as string.
+// Sensitive values are returned as they are.
+// Use ValueAs to get the value as a specific type.
+func ValueOf(err error, key string) (interface{}, bool) {
+	if err == nil {
+		return nil, false
+	}
+
+	i := NewIterator(err)
+	for i.Next() {
+		var (
+			tc  TypedContext
+			ctx Context
+		)
+		node := &Iterator{err: unredactedOf(i.Error())}
+		switch {
+		case node.As(&tc):
+			if v, ok := tc[key]; ok {
+				return v, true
+			}
+		case node.As(&ctx):
+			if v, ok := ctx[key]; ok {
+				return v, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ValueAs extracts a value of the key like ValueOf, and returns it if the
+// value is of type T.
+//
+//	userID, ok := failure.ValueAs[int64](err, "user_id")
+func ValueAs[T any](err error, key string) (T, bool) {
+	v, ok := ValueOf(err, key)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	t, ok := v.(T)
+	return t, ok
+}
+
 // WithCallStackSkip appends a call stack to the err skipping first N frames.
 // You don't have to use this directly, unless using function Custom.
 func WithCallStackSkip(skip int) Wrapper {
@@ -193,6 +484,17 @@ func WithCallStackSkip(skip int) Wrapper {
 	})
 }
 
+// WithCallStack appends the call stack to the err.
+// You don't have to use this directly, unless using function Custom.
+func WithCallStack(cs CallStack) Wrapper {
+	return WrapperFunc(func(err error) error {
+		return &withCallStack{
+			cs,
+			err,
+		}
+	})
+}
+
 type withCallStack struct {
 	callStack  CallStack
 	underlying error
@@ -226,26 +528,46 @@ func (w *withCallStack) As(x interface{}) bool {
 
 // CallStackOf extracts a call stack from the err.
 // Returned call stack is for the most deepest place (appended first).
+// If the err contains multiple errors, the deepest call stack in the
+// first branch having a call stack is returned. If no branch has a call
+// stack, the deepest call stack wrapping the branches is returned.
 func CallStackOf(err error) (CallStack, bool) {
-	if err == nil {
-		return nil, false
-	}
+	var last CallStack
+	for err != nil {
+		var errs []error
+		for _, e := range unwrapErrors(err) {
+			if e != nil {
+				errs = append(errs, e)
+			}
+		}
 
-	var (
-		last   CallStack
-		exists bool
-	)
-	i := NewIterator(err)
-	for i.Next() {
-		exists = i.As(&last) || exists
-	}
+		i := &Iterator{err: err}
+		var cs CallStack
+		if i.As(&cs) {
+			last = cs
+		}
+
+		if len(errs) > 1 {
+			for _, e := range errs {
+				if cs, ok := CallStackOf(e); ok {
+					return cs, true
+				}
+			}
+			break
+		}
 
-	return last, exists
+		err = nil
+		if len(errs) == 1 {
+			err = errs[0]
+		}
+	}
+	return last, last != nil
 }
 
 // WithFormatter appends an error formatter to the err.
 //
 //	%v+: Print trace for each place, and deepest call stack.
+//	     Use AllCallStacks to print every call stack.
 //	%#v: Print raw structure of the error.
 //	others (%s, %v): Same as err.Error().
 //
@@ -286,44 +608,65 @@ func (f *formatter) Format(s fmt.State, verb rune) {
 	}
 
 	// %+v
+	formatChain(s, f.error, false)
+	formatCallStacks(s, f.error, false)
+}
+
+// formatChain prints the trace of the err for %+v.
+// Each branch of multiple errors is printed after a branch header
+// numbered by its path from the top (e.g. [Branch 1.2]).
+// Codes which are not effective are marked with their status.
+func formatChain(s fmt.State, err error, reveal bool) {
 	type formatter interface {
 		IsFormatter()
 	}
 
-	i := NewIterator(f.error)
-	for i.Next() {
-		err := i.Error()
-		if _, ok := err.(formatter); ok {
-			continue
-		}
-		var (
-			cs   CallStack
-			ctx  Contexter
-			msg  Messenger
-			code Code
-		)
-		switch {
-		case i.As(&cs):
-			fmt.Fprintf(s, "%+v\n", cs.HeadFrame())
-		case i.As(&ctx):
-			for k, v := range ctx.Context() {
-				fmt.Fprintf(s, "    %s = %s\n", k, v)
+	walkChain(err, chainVisitor{
+		enter: func(branch string) {
+			if branch != "" {
+				fmt.Fprintf(s, "[Branch %s]\n", branch)
 			}
-		case i.As(&msg):
-			fmt.Fprintf(s, "    message(%q)\n", msg)
-		case i.As(&code):
-			fmt.Fprintf(s, "    code(%s)\n", code.ErrorCode())
-		default:
-			fmt.Fprintf(s, "    %T(%q)\n", err, err.Error())
-		}
-	}
-
-	fmt.Fprint(s, "[CallStack]\n")
-	if cs, ok := CallStackOf(f); ok {
-		for _, f := range cs.Frames() {
-			fmt.Fprintf(s, "    %+v\n", f)
-		}
-	}
+		},
+		visit: func(n chainNode) {
+			i := &Iterator{err: n.err}
+			if reveal {
+				i = &Iterator{err: unredactedOf(n.err)}
+			}
+			var (
+				cs   CallStack
+				ctx  OrderedContext
+				msg  Messenger
+				pub  PublicMessage
+				id   MessageID
+				code Code
+			)
+			switch _, isFormatter := n.err.(formatter); {
+			case isFormatter:
+			case i.As(&cs):
+				fmt.Fprintf(s, "%+v\n", cs.HeadFrame())
+			case i.As(&ctx):
+				for _, kv := range ctx {
+					fmt.Fprintf(s, "    %s = %s\n", kv.Key, kv.Value)
+				}
+			case i.As(&msg):
+				fmt.Fprintf(s, "    message(%q)\n", msg)
+			case i.As(&pub):
+				fmt.Fprintf(s, "    public_message(%q)\n", pub)
+			case i.As(&id):
+				fmt.Fprintf(s, "    message_id(%q)\n", id)
+			case i.As(&code):
+				if status := n.scope.status(); code != nil && status != CodeEffective {
+					fmt.Fprintf(s, "    code(%s) (%s)\n", codeDetail(code), status)
+				} else {
+					fmt.Fprintf(s, "    code(%s)\n", codeDetail(code))
+				}
+			case len(n.errs) > 1:
+				fmt.Fprintf(s, "    %T(%d errors)\n", n.err, len(n.errs))
+			default:
+				fmt.Fprintf(s, "    %T(%q)\n", n.err, n.err.Error())
+			}
+		},
+	})
 }
 
 // WithUnexpected wraps the err to mark it is unexpected.
@@ -340,7 +683,7 @@ type withUnexpected struct {
 }
 
 func (w *withUnexpected) Unwrap() error {
-	return w.underlying
+	return hideCode(w.underlying)
 }
 
 func (w *withUnexpected) Error() string {
@@ -2,6 +2,7 @@ package failure_test
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"testing"
 
@@ -51,3 +52,152 @@ func TestErrorsAs(t *testing.T) {
 	failure.Trace(err, &tracer)
 	shouldEqual(t, len(tracer), 3)
 }
+
+func TestFormatter_MultiError(t *testing.T) {
+	err := failure.Wrap(errors.Join(
+		failure.New(TestCodeA),
+		errors.Join(io.EOF, failure.Unexpected("xxx")),
+	))
+
+	cs, ok := failure.CallStackOf(err)
+	shouldEqual(t, ok, true)
+	shouldEqual(t, cs.HeadFrame().Line(), 58)
+
+	exp := `\[failure_test.TestFormatter_MultiError\] /.*/failure/wrapper_test.go:57
+    \*errors.joinError\(2 errors\)
+\[Branch 1\]
+\[failure_test.TestFormatter_MultiError\] /.*/failure/wrapper_test.go:58
+    code\(code_a\)
+\[Branch 2\]
+    \*errors.joinError\(2 errors\)
+\[Branch 2.1\]
+    \*errors.errorString\("EOF"\)
+\[Branch 2.2\]
+\[failure_test.TestFormatter_MultiError\] /.*/failure/wrapper_test.go:59
+    failure.unexpected\("xxx"\)
+\[CallStack\]
+    \[failure_test.TestFormatter_MultiError\] /.*/failure/wrapper_test.go:58
+    \[.*`
+	shouldMatch(t, fmt.Sprintf("%+v", err), exp)
+
+	var ss failure.StringTracer
+	failure.Trace(err, &ss)
+	shouldEqual(t, len(ss), 5)
+	shouldMatch(t, ss[4], "unexpected: xxx")
+}
+
+func TestContext_Order(t *testing.T) {
+	ctx := failure.Context{}
+	for _, k := range []string{"e", "b", "d", "a", "c"} {
+		ctx[k] = k
+	}
+	err := failure.Wrap(io.EOF, ctx, failure.OrderedContext{{"z", "1"}, {"y", "2"}, {"z", "3"}})
+
+	shouldEqual(t, err.Error(), "failure_test.TestContext_Order: a=a b=b c=c d=d e=e: z=3 y=2: EOF")
+	shouldMatch(t, fmt.Sprintf("%+v", err), `^\[failure_test.TestContext_Order\] /.*/failure/wrapper_test.go:94
+    a = a
+    b = b
+    c = c
+    d = d
+    e = e
+    z = 3
+    y = 2
+    \*errors.errorString\("EOF"\)
+`)
+
+	var st failure.StringTracer
+	failure.Trace(err, &st)
+	shouldEqual(t, st[1:], failure.StringTracer{"a = a", "b = b", "c = c", "d = d", "e = e", "z = 3", "y = 2"})
+
+	var (
+		cs  []failure.Context
+		ocs []failure.OrderedContext
+	)
+	i := failure.NewIterator(err)
+	for i.Next() {
+		var (
+			c  failure.Context
+			oc failure.OrderedContext
+		)
+		if i.As(&c) && i.As(&oc) {
+			cs = append(cs, c)
+			ocs = append(ocs, oc)
+		}
+	}
+	shouldEqual(t, cs, []failure.Context{ctx, {"z": "3", "y": "2"}})
+	shouldEqual(t, ocs, []failure.OrderedContext{
+		{{"a", "a"}, {"b", "b"}, {"c", "c"}, {"d", "d"}, {"e", "e"}},
+		{{"z", "3"}, {"y", "2"}},
+	})
+}
+
+type lazyValue struct {
+	n *int
+}
+
+func (v lazyValue) String() string {
+	*v.n++
+	return fmt.Sprint(*v.n)
+}
+
+func TestTypedContext(t *testing.T) {
+	var calls int
+	err := failure.New(TestCodeA,
+		failure.TypedContext{"user_id": 1, "lazy": lazyValue{&calls}},
+		failure.Context{"user_id": "2", "name": "x"},
+	)
+	shouldEqual(t, calls, 0)
+	shouldMatch(t, err.Error(), `^failure_test.TestTypedContext: lazy=1 user_id=1: name=x user_id=2: code\(code_a\)$`)
+	shouldMatch(t, err.Error(), `lazy=1 `)
+	shouldEqual(t, calls, 1)
+
+	v, ok := failure.ValueOf(err, "user_id")
+	shouldEqual(t, ok, true)
+	shouldEqual(t, v, 1)
+	v, ok = failure.ValueOf(err, "name")
+	shouldEqual(t, ok, true)
+	shouldEqual(t, v, "x")
+	_, ok = failure.ValueOf(err, "unknown")
+	shouldEqual(t, ok, false)
+	_, ok = failure.ValueOf(nil, "user_id")
+	shouldEqual(t, ok, false)
+
+	var ctx failure.Contexter
+	shouldEqual(t, errors.As(err, &ctx), true)
+	shouldEqual(t, ctx.Context(), failure.Context{"user_id": "1", "lazy": "1"})
+
+	var st failure.StringTracer
+	failure.Trace(err, &st)
+	shouldMatch(t, fmt.Sprint(st), `lazy = \d+ user_id = 1 name = x user_id = 2`)
+
+	var vs valueTracer
+	failure.Trace(err, &vs)
+	shouldEqual(t, vs[1], failure.TypedContext{"user_id": 1, "lazy": lazyValue{&calls}})
+
+	shouldMatch(t, fmt.Sprintf("%+v", err), `\n    lazy = 1\n    user_id = 1\n    name = x\n    user_id = 2\n`)
+}
+
+type valueTracer []interface{}
+
+func (vt *valueTracer) Push(v interface{}) {
+	*vt = append(*vt, v)
+}
+
+func TestCallStackOf_MultiError(t *testing.T) {
+	inner := failure.New(TestCodeB)
+	outer := failure.Wrap(errors.Join(io.EOF, inner))
+
+	cs, ok := failure.CallStackOf(outer)
+	shouldEqual(t, ok, true)
+	want, _ := failure.CallStackOf(inner)
+	shouldEqual(t, cs.Frames(), want.Frames())
+
+	plain := failure.Wrap(errors.Join(io.EOF, io.ErrUnexpectedEOF))
+	cs, ok = failure.CallStackOf(plain)
+	shouldEqual(t, ok, true)
+	shouldEqual(t, cs.HeadFrame().Func(), "TestCallStackOf_MultiError")
+
+	cs, ok = failure.CallStackOf(errors.Join(io.EOF, io.ErrUnexpectedEOF))
+	shouldEqual(t, ok, false)
+	shouldEqual(t, cs, nil)
+}